@@ -1,14 +1,43 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mini-poc-02/backend/internal/apikey"
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/cdc"
+	"mini-poc-02/backend/internal/changefeed"
 	"mini-poc-02/backend/internal/config"
+	"mini-poc-02/backend/internal/cors"
 	"mini-poc-02/backend/internal/db"
+	"mini-poc-02/backend/internal/heartbeat"
+	"mini-poc-02/backend/internal/history"
 	"mini-poc-02/backend/internal/httpapi"
+	"mini-poc-02/backend/internal/kafkaconnect"
+	"mini-poc-02/backend/internal/logging"
+	"mini-poc-02/backend/internal/metrics"
+	"mini-poc-02/backend/internal/notify"
+	"mini-poc-02/backend/internal/pii"
+	"mini-poc-02/backend/internal/ratelimit"
+	"mini-poc-02/backend/internal/rbac"
+	"mini-poc-02/backend/internal/realip"
+	"mini-poc-02/backend/internal/reconcile"
+	"mini-poc-02/backend/internal/tracing"
 )
 
 func main() {
@@ -17,6 +46,13 @@ func main() {
 		log.Fatalf("config error: %v", err)
 	}
 
+	// slog.SetDefault juga mengarahkan log.Printf/Fatalf lama ke handler ini
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("logging config error: %v", err)
+	}
+	slog.SetDefault(logger)
+
 	// d, err := db.Open(cfg.MySQLDSN())
 	dbConn, err := db.OpenPostgres(cfg.PostgresDSN())
 	if err != nil {
@@ -25,8 +61,285 @@ func main() {
 	// defer d.SQL.Close()
 	defer dbConn.Close()
 
+	// context untuk background worker (berhenti saat main selesai)
+	bgCtx, cancelBg := context.WithCancel(context.Background())
+	defer cancelBg()
+
 	// handlers := httpapi.NewHandlers(d.SQL)
 	handlers := httpapi.NewHandlers(dbConn)
+	handlers.Logger = logger
+	handlers.AccessLog = cfg.AccessLog
+	handlers.BaseContext = bgCtx
+	if cfg.MetricsEnabled {
+		m := metrics.New()
+		for _, c := range []prometheus.Collector{metrics.DBStats("ods", dbConn), handlers.SyncLagCollector()} {
+			if err := m.Register(c); err != nil {
+				log.Fatalf("metrics: register collector: %v", err)
+			}
+		}
+		handlers.Metrics = m
+		handlers.MetricsPublic = cfg.MetricsPublic
+	}
+	if cfg.TracesExporter != "none" {
+		// ditutup saat graceful shutdown di akhir main supaya span yang antre terkirim
+		tracer, err := tracing.New(context.Background(), tracing.Config{
+			ServiceName: cfg.TracingServiceName,
+			Exporter:    cfg.TracesExporter,
+			Endpoint:    cfg.OTLPEndpoint,
+			Headers:     cfg.OTLPHeaders,
+			Sampler:     cfg.TracesSampler,
+			SamplerArg:  cfg.TracesSamplerArg,
+		})
+		if err != nil {
+			log.Fatalf("tracing config error: %v", err)
+		}
+		handlers.Tracer = tracer
+	}
+	handlers.KPIAnomalies = httpapi.NewKPIAnomalyDetector(httpapi.KPIAnomalyConfig{
+		Window:         cfg.KPIAnomalyWindow,
+		MinSamples:     cfg.KPIAnomalyMinSamples,
+		ZScore:         cfg.KPIAnomalyZScore,
+		PctChange:      cfg.KPIAnomalyPctChange,
+		SampleInterval: cfg.KPIAnomalySampleInterval,
+	})
+	sla, err := httpapi.LoadSLAConfig(cfg.SLAPolicyFile, httpapi.SLAPolicy{
+		LagWarnSeconds:         int(cfg.SLALagWarn / time.Second),
+		LagErrorSeconds:        int(cfg.SLALagError / time.Second),
+		MaxSinceSuccessSeconds: int(cfg.SLAMaxSinceSuccess / time.Second),
+		MaxAuditAgeSeconds:     int(cfg.SLAMaxAuditAge / time.Second),
+		FreshnessWarnSeconds:   int(cfg.TableFreshnessWarn / time.Second),
+		FreshnessErrorSeconds:  int(cfg.TableFreshnessError / time.Second),
+	})
+	if err != nil {
+		log.Fatalf("sla config error: %v", err)
+	}
+	handlers.SLA = sla
+	handlers.DataFreshness = httpapi.NewDataFreshness(handlers, httpapi.DataFreshnessConfig{
+		Mode:        cfg.FreshnessMode,
+		CacheTTL:    cfg.FreshnessCacheTTL,
+		MetaDefault: cfg.FreshnessMetaDefault,
+	})
+	if cfg.KafkaConnectURL != "" {
+		handlers.Connectors = &httpapi.ConnectorProbe{
+			Client:     kafkaconnect.NewClient(cfg.KafkaConnectURL, cfg.KafkaConnectTimeout),
+			Connectors: cfg.KafkaConnectConnectors,
+		}
+	}
+
+	// MySQL source opsional: dipakai untuk rekonsiliasi source vs ODS
+	if cfg.HasSourceDB() {
+		src, err := db.Open(cfg.MySQLDSN())
+		if err != nil {
+			log.Fatalf("source db open error: %v", err)
+		}
+		defer src.SQL.Close()
+
+		handlers.Reconciler = reconcile.New(src.SQL, dbConn, reconcile.Config{
+			ChunkSize: cfg.ReconcileChunkSize,
+			MaxDiffs:  cfg.ReconcileMaxDiffs,
+		})
+		if cfg.ReconcileInterval > 0 {
+			go handlers.Reconciler.Start(bgCtx, cfg.ReconcileInterval, logger.With("component", "reconcile"))
+		}
+
+		if cfg.HeartbeatInterval > 0 {
+			hb, err := heartbeat.New(src.SQL, dbConn, heartbeat.Config{
+				Interval:     cfg.HeartbeatInterval,
+				Timeout:      cfg.HeartbeatTimeout,
+				PollInterval: cfg.HeartbeatPollInterval,
+				SourceTable:  cfg.HeartbeatSourceTable,
+				TargetTable:  cfg.HeartbeatTargetTable,
+				AuditTable:   cfg.HeartbeatAuditTable,
+			})
+			if err != nil {
+				log.Fatalf("heartbeat config error: %v", err)
+			}
+			handlers.Heartbeat = hb
+			go hb.Start(bgCtx, logger.With("component", "heartbeat"))
+		}
+	}
+	if cfg.HistoryMode != "" {
+		ctx, cancel := context.WithTimeout(bgCtx, 10*time.Second)
+		err := history.EnsureSchema(ctx, dbConn)
+		if err == nil && cfg.HistoryMode == history.SourceTrigger {
+			err = history.InstallTriggers(ctx, dbConn)
+		}
+		cancel()
+		if err != nil {
+			log.Fatalf("history: setup (%s) failed: %v", cfg.HistoryMode, err)
+		}
+		handlers.HistoryEnabled = true
+		handlers.HistoryAsOf = cfg.HistoryMode == history.SourceTrigger
+	}
+	if cfg.CDCEventsSource != "" {
+		src, err := openCDCSource(cfg.CDCEventsSource)
+		if err != nil {
+			log.Fatalf("cdc source error: %v", err)
+		}
+		ing := cdc.NewIngestor(src, &cdc.SQLAuditWriter{DB: dbConn}, cdc.IngestorConfig{
+			FlushInterval: cfg.CDCFlushInterval,
+		})
+		if cfg.HistoryMode == history.SourceDebezium {
+			ing.OnEvent = (&history.Recorder{DB: dbConn}).RecordEvent
+		}
+		go func() {
+			defer src.Close()
+			if err := ing.Run(bgCtx, logger.With("component", "cdc")); err != nil {
+				logger.Error("cdc ingestor stopped", "error", err)
+			}
+		}()
+	}
+
+	handlers.Dashboard = httpapi.NewDashboardHub(handlers, httpapi.DashboardStreamConfig{
+		MaxSubscribers:    cfg.StreamMaxSubscribers,
+		SyncPollInterval:  cfg.StreamSyncPollInterval,
+		KPIInterval:       cfg.StreamKPIInterval,
+		HeartbeatInterval: cfg.StreamHeartbeatInterval,
+	})
+	go handlers.Dashboard.Start(bgCtx, logger.With("component", "dashboard"))
+
+	if cfg.ChangeFeedEnabled {
+		if cfg.ChangeFeedInstallTriggers {
+			ctx, cancel := context.WithTimeout(bgCtx, 10*time.Second)
+			err := changefeed.InstallTriggers(ctx, dbConn, cfg.ChangeFeedChannel)
+			cancel()
+			if err != nil {
+				log.Fatalf("changefeed: install triggers failed: %v", err)
+			}
+		}
+
+		handlers.ChangeFeed = changefeed.NewBroker(cfg.ChangeFeedMaxSubscribers)
+		listener := &changefeed.Listener{
+			DSN:     cfg.PostgresDSN(),
+			Channel: cfg.ChangeFeedChannel,
+			Broker:  handlers.ChangeFeed,
+		}
+		go func() {
+			if err := listener.Run(bgCtx, logger.With("component", "changefeed")); err != nil {
+				logger.Error("changefeed listener stopped", "error", err)
+			}
+		}()
+	}
+
+	if len(cfg.SyncWebhookURLs) > 0 {
+		n, err := notify.New(handlers.SyncStatusCheck, notify.Config{
+			URLs:        cfg.SyncWebhookURLs,
+			Secret:      cfg.SyncWebhookSecret,
+			Interval:    cfg.SyncNotifyInterval,
+			MaxAttempts: cfg.SyncWebhookMaxAttempts,
+			Backoff:     cfg.SyncWebhookBackoff,
+			Timeout:     cfg.SyncWebhookTimeout,
+			DedupWindow: cfg.SyncNotifyDedupWindow,
+		})
+		if err != nil {
+			log.Fatalf("notify config error: %v", err)
+		}
+		handlers.Notifier = n
+		go n.Start(bgCtx, logger.With("component", "notify"))
+	}
+
+	if cfg.AuthIssuer != "" {
+		v, err := newVerifier(bgCtx, cfg)
+		if err != nil {
+			log.Fatalf("auth config error: %v", err)
+		}
+		handlers.Auth = v
+
+		policy, err := rbac.LoadPolicy(cfg.RBACPolicyFile)
+		if err != nil {
+			log.Fatalf("rbac config error: %v", err)
+		}
+		handlers.RBAC, err = rbac.NewEnforcer(policy)
+		if err != nil {
+			log.Fatalf("rbac config error: %v", err)
+		}
+
+		if cfg.APIKeysEnabled {
+			ctx, cancel := context.WithTimeout(bgCtx, 10*time.Second)
+			err := apikey.EnsureSchema(ctx, dbConn)
+			cancel()
+			if err != nil {
+				log.Fatalf("api keys: setup failed: %v", err)
+			}
+			store := &apikey.Store{DB: dbConn}
+			v.APIKeys = store
+			handlers.APIKeys = &httpapi.APIKeyAdmin{Store: store, DefaultTTL: cfg.APIKeyDefaultTTL}
+		}
+	}
+
+	if cfg.PIIMasking {
+		policy, err := pii.LoadPolicy(cfg.PIIPolicyFile)
+		if err != nil {
+			log.Fatalf("pii config error: %v", err)
+		}
+		handlers.PII, err = pii.NewMasker(policy)
+		if err != nil {
+			log.Fatalf("pii config error: %v", err)
+		}
+	}
+
+	if cfg.AuditEnabled {
+		ctx, cancel := context.WithTimeout(bgCtx, 10*time.Second)
+		var err error
+		if cfg.AuditManageSchema {
+			err = audit.EnsureSchema(ctx, dbConn)
+		}
+		var (
+			owner      string
+			ownedByApp bool
+		)
+		if err == nil {
+			owner, ownedByApp, err = audit.CheckOwnership(ctx, dbConn)
+		}
+		cancel()
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("table %s does not exist (create it or set AUDIT_MANAGE_SCHEMA=true)", audit.Table)
+		}
+		if err != nil {
+			log.Fatalf("audit: setup failed: %v", err)
+		}
+		if ownedByApp {
+			logger.Warn("audit: table is owned by the application role, so its append-only triggers can be dropped; "+
+				"transfer ownership to a separate role and grant the app INSERT, SELECT only",
+				"table", audit.Table, "owner", owner)
+		}
+		handlers.Audit = &httpapi.AccessAudit{Log: &audit.Log{DB: dbConn}, FailOpen: cfg.AuditFailOpen}
+	}
+
+	if handlers.RealIP, err = realip.New(cfg.TrustedProxies); err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+
+	if cfg.RateLimitEnabled {
+		rlCfg, err := ratelimit.LoadConfig(cfg.RateLimitFile, ratelimit.Rule{
+			Rate:          cfg.RateLimitRPS,
+			Burst:         cfg.RateLimitBurst,
+			MaxConcurrent: cfg.RateLimitMaxConcurrent,
+		})
+		if err != nil {
+			log.Fatalf("rate limit config error: %v", err)
+		}
+		handlers.RateLimit, err = ratelimit.New(rlCfg)
+		if err != nil {
+			log.Fatalf("rate limit config error: %v", err)
+		}
+	}
+
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		c, err := cors.New(cors.Config{
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowedMethods:   cfg.CORSAllowedMethods,
+			AllowedHeaders:   cfg.CORSAllowedHeaders,
+			ExposedHeaders:   cfg.CORSExposedHeaders,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		})
+		if err != nil {
+			log.Fatalf("cors config error: %v", err)
+		}
+		handlers.CORS = c
+	}
+
 	router := httpapi.NewRouter(handlers)
 
 	addr := ":" + cfg.AppPort
@@ -43,10 +356,98 @@ func main() {
 		log.Fatalf("failed to bind %s (is it already in use?): %v", addr, err)
 	}
 
-	log.Printf("API listening on http://localhost:%s", cfg.AppPort)
+	logger.Info("API listening", "addr", "http://localhost:"+cfg.AppPort, "log_level", cfg.LogLevel, "access_log", cfg.AccessLog)
+
+	// SIGINT/SIGTERM memicu graceful shutdown: berhenti menerima koneksi baru, tunggu request
+	// yang berjalan, hentikan worker latar, lalu flush span yang masih antre.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Serve menggunakan listener yang sudah dipastikan berhasil.
-	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server error: %v", err)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		logger.Error("server error", "error", err)
+		exitCode = 1
+	case <-ctx.Done():
+		logger.Info("shutting down", "timeout", shutdownTimeout.String())
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// stream SSE tidak pernah selesai sendiri: putus paksa setelah timeout
+		logger.Warn("graceful shutdown timed out, closing remaining connections", "error", err)
+		_ = srv.Close()
+	}
+	cancel()
+	cancelBg()
+
+	// timeout sendiri: shutdownCtx bisa sudah habis dipakai menunggu stream SSE
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := handlers.Tracer.Shutdown(flushCtx); err != nil {
+		logger.Warn("tracing: flush on shutdown failed", "error", err)
+	}
+	cancel()
+
+	if exitCode != 0 {
+		dbConn.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// shutdownTimeout: batas waktu menunggu request berjalan dan flush span saat shutdown.
+const shutdownTimeout = 15 * time.Second
+
+// openCDCSource membuka sumber event Debezium dari konfigurasi CDC_EVENTS_SOURCE.
+// Consumer Kafka asli belum dibundel; implementasikan cdc.KafkaConsumer lalu bungkus dengan cdc.NewKafkaSource.
+func openCDCSource(spec string) (cdc.Source, error) {
+	switch {
+	case spec == "stdin":
+		return cdc.NewReaderSource(os.Stdin), nil
+	case strings.HasPrefix(spec, "file:"):
+		f, err := os.Open(strings.TrimPrefix(spec, "file:"))
+		if err != nil {
+			return nil, err
+		}
+		return cdc.NewReaderSource(f), nil
+	default:
+		return nil, fmt.Errorf("unsupported CDC_EVENTS_SOURCE %q (use stdin or file:/path)", spec)
+	}
+}
+
+// newVerifier menyiapkan verifikasi JWT. JWKS dimuat saat startup supaya issuer/JWKS
+// yang salah langsung gagal, bukan saat request pertama.
+func newVerifier(ctx context.Context, cfg config.Config) (*auth.Verifier, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	source := cfg.AuthJWKS
+	if source == "" {
+		uri, err := auth.DiscoverJWKS(ctx, cfg.AuthIssuer)
+		if err != nil {
+			return nil, err
+		}
+		source = uri
+	}
+
+	keys := auth.NewKeySet(source, cfg.AuthJWKSRefresh)
+	if err := keys.Load(ctx); err != nil {
+		return nil, err
+	}
+	public := []string{"/api/v1/health"}
+	if cfg.MetricsPublic {
+		public = append(public, "/metrics")
 	}
+	return auth.NewVerifier(keys, auth.Config{
+		Issuer:     cfg.AuthIssuer,
+		Audience:   cfg.AuthAudience,
+		RolesClaim: cfg.AuthRolesClaim,
+		Leeway:     cfg.AuthLeeway,
+		Public:     public,
+		QueryToken: []string{"/api/v1/stream/dashboard", "/api/v1/customers/{customer_id}/events"},
+	})
 }
@@ -0,0 +1,291 @@
+// internal/apikey/store.go
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/logging"
+)
+
+// Table: API key untuk klien service-to-service (batch job, BI). Yang disimpan hanya
+// SHA-256 dari key; plaintext hanya dikembalikan sekali saat Create.
+const Table = "api_keys"
+
+// Prefix plaintext key: mks_<id>_<secret>. id disimpan apa adanya untuk lookup.
+const Prefix = "mks_"
+
+// lastUsedResolution: last_used_at hanya ditulis ulang kalau lebih tua dari ini,
+// supaya tiap request tidak menghasilkan UPDATE.
+const lastUsedResolution = time.Minute
+
+// Error penolakan key; cocok dengan auth.ErrInvalidCredentials (401). Error lain dari
+// Authenticate (DB down, dsb.) bukan kesalahan klien dan dijawab 503 oleh middleware auth.
+var (
+	ErrInvalidKey = credentialError("invalid API key")
+	ErrRevoked    = credentialError("API key has been revoked")
+	ErrExpired    = credentialError("API key has expired")
+	ErrNotFound   = errors.New("API key not found")
+)
+
+type credentialError string
+
+func (e credentialError) Error() string { return string(e) }
+
+func (e credentialError) Is(target error) bool { return target == auth.ErrInvalidCredentials }
+
+// Key adalah metadata API key (tanpa hash).
+type Key struct {
+	ID     string   `json:"id"`
+	Prefix string   `json:"prefix"` // mks_<id>, untuk mengenali key di log klien
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// RowScopes: batasan baris per permission yang dimiliki pembuat saat key dibuat
+	RowScopes  map[string][]map[string]string `json:"row_scopes,omitempty"`
+	CreatedBy  string                         `json:"created_by"`
+	CreatedAt  time.Time                      `json:"created_at"`
+	ExpiresAt  *time.Time                     `json:"expires_at"`
+	LastUsedAt *time.Time                     `json:"last_used_at"`
+	RevokedAt  *time.Time                     `json:"revoked_at"`
+}
+
+// EnsureSchema membuat tabel api_keys kalau belum ada. Kolom row_scopes ditambahkan
+// belakangan; key lama tanpa isinya tetap tanpa batasan baris.
+func EnsureSchema(ctx context.Context, db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+			id           TEXT PRIMARY KEY,
+			name         TEXT        NOT NULL,
+			key_hash     TEXT        NOT NULL,
+			scopes       JSONB       NOT NULL DEFAULT '[]',
+			row_scopes   JSONB       NOT NULL DEFAULT '{}',
+			created_by   TEXT        NOT NULL DEFAULT '',
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+			expires_at   TIMESTAMPTZ,
+			last_used_at TIMESTAMPTZ,
+			revoked_at   TIMESTAMPTZ
+		)`,
+		`ALTER TABLE ` + Table + ` ADD COLUMN IF NOT EXISTS row_scopes JSONB NOT NULL DEFAULT '{}'`,
+	}
+	for _, q := range stmts {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("ensure api key schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Store menyimpan dan memverifikasi API key.
+type Store struct {
+	DB *sql.DB
+
+	mu      sync.Mutex
+	touched map[string]time.Time // touch terakhir per key dari proses ini (hanya key yang lolos verifikasi)
+}
+
+// Create membuat key baru dan mengembalikan plaintext-nya (tidak bisa diambil lagi).
+// rowScopes: batasan baris per permission (nil = tanpa batasan), lihat Key.RowScopes.
+func (s *Store) Create(ctx context.Context, name string, scopes []string, rowScopes map[string][]map[string]string, expiresAt *time.Time, createdBy string) (Key, string, error) {
+	idBytes := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return Key{}, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, "", err
+	}
+	id := hex.EncodeToString(idBytes)
+	plaintext := Prefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	rawScopes, err := json.Marshal(scopes)
+	if err != nil {
+		return Key{}, "", err
+	}
+	if rowScopes == nil {
+		rowScopes = map[string][]map[string]string{}
+	}
+	rawRowScopes, err := json.Marshal(rowScopes)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	k := Key{ID: id, Prefix: Prefix + id, Name: name, Scopes: scopes, RowScopes: rowScopes, CreatedBy: createdBy, ExpiresAt: expiresAt}
+	err = s.DB.QueryRowContext(ctx, `
+		INSERT INTO `+Table+` (id, name, key_hash, scopes, row_scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4::jsonb, $5::jsonb, $6, $7)
+		RETURNING created_at
+	`, id, name, hashKey(plaintext), string(rawScopes), string(rawRowScopes), createdBy, expiresAt).Scan(&k.CreatedAt)
+	if err != nil {
+		return Key{}, "", fmt.Errorf("insert %s: %w", Table, err)
+	}
+	return k, plaintext, nil
+}
+
+// List mengembalikan semua key (termasuk yang sudah revoked/expired), terbaru lebih dulu.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, name, scopes, row_scopes, created_by, created_at, expires_at, last_used_at, revoked_at
+		FROM `+Table+`
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", Table, err)
+	}
+	defer rows.Close()
+
+	out := []Key{}
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// Revoke menandai key tidak berlaku. Baris tetap disimpan supaya jejak audit tetap utuh.
+func (s *Store) Revoke(ctx context.Context, id string) (Key, error) {
+	row := s.DB.QueryRowContext(ctx, `
+		UPDATE `+Table+`
+		SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1
+		RETURNING id, name, scopes, row_scopes, created_by, created_at, expires_at, last_used_at, revoked_at
+	`, id)
+	k, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Key{}, ErrNotFound
+	}
+	return k, err
+}
+
+// Authenticate memverifikasi plaintext key dan mengembalikan principal-nya
+// (subject "apikey:<id>", Scopes = scope key). Mengimplementasikan auth.APIKeyAuthenticator.
+func (s *Store) Authenticate(ctx context.Context, plaintext string) (*auth.Principal, error) {
+	id, ok := parseID(plaintext)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	var hash string
+	row := s.DB.QueryRowContext(ctx, `
+		SELECT key_hash, id, name, scopes, row_scopes, created_by, created_at, expires_at, last_used_at, revoked_at
+		FROM `+Table+`
+		WHERE id = $1
+	`, id)
+	k, err := scanKey(row, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashKey(plaintext))) != 1 {
+		return nil, ErrInvalidKey
+	}
+	switch {
+	case k.RevokedAt != nil:
+		return nil, ErrRevoked
+	case k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt):
+		return nil, ErrExpired
+	}
+
+	if (k.LastUsedAt == nil || time.Since(*k.LastUsedAt) > lastUsedResolution) && s.claimTouch(id, time.Now()) {
+		go s.touch(context.WithoutCancel(ctx), id)
+	}
+
+	return &auth.Principal{
+		Subject:   "apikey:" + k.ID,
+		Issuer:    "api-key",
+		Name:      k.Name,
+		Roles:     []string{},
+		Scopes:    k.Scopes,
+		RowScopes: k.RowScopes,
+	}, nil
+}
+
+// claimTouch: paling banyak satu UPDATE last_used_at per key per lastUsedResolution.
+// Tanpa ini, burst request sebelum UPDATE pertama selesai masing-masing melihat
+// last_used_at lama dan menjalankan goroutine UPDATE sendiri.
+func (s *Store) claimTouch(id string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < lastUsedResolution {
+		return false
+	}
+	if s.touched == nil {
+		s.touched = map[string]time.Time{}
+	}
+	s.touched[id] = now
+	return true
+}
+
+// touch menulis last_used_at di background: hanya informasi, jadi gagal update
+// cukup di-log dan tidak boleh menggagalkan atau menunda request.
+func (s *Store) touch(ctx context.Context, id string) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.DB.ExecContext(ctx, `UPDATE `+Table+` SET last_used_at = now() WHERE id = $1`, id); err != nil {
+		logging.FromContext(ctx).Warn("apikey: update last_used_at failed", "key_id", id, "error", err)
+	}
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanKey membaca kolom metadata; extra (mis. key_hash) dibaca lebih dulu kalau ada.
+func scanKey(row scanner, extra ...any) (Key, error) {
+	var (
+		k         Key
+		scopes    []byte
+		rowScopes []byte
+	)
+	dest := append(extra, &k.ID, &k.Name, &scopes, &rowScopes, &k.CreatedBy, &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt)
+	if err := row.Scan(dest...); err != nil {
+		return Key{}, err
+	}
+	if err := json.Unmarshal(scopes, &k.Scopes); err != nil {
+		return Key{}, fmt.Errorf("decode scopes of key %s: %w", k.ID, err)
+	}
+	if err := json.Unmarshal(rowScopes, &k.RowScopes); err != nil {
+		return Key{}, fmt.Errorf("decode row scopes of key %s: %w", k.ID, err)
+	}
+	if len(k.RowScopes) == 0 {
+		k.RowScopes = nil
+	}
+	k.Prefix = Prefix + k.ID
+	return k, nil
+}
+
+func parseID(plaintext string) (string, bool) {
+	if !strings.HasPrefix(plaintext, Prefix) {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(strings.TrimPrefix(plaintext, Prefix), "_")
+	if !ok || len(id) != 16 || secret == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// hashKey: key acak 256-bit, jadi SHA-256 tanpa salt/KDF sudah cukup.
+func hashKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
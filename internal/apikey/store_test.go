@@ -0,0 +1,86 @@
+// internal/apikey/store_test.go
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mini-poc-02/backend/internal/auth"
+)
+
+func TestCredentialErrors(t *testing.T) {
+	for _, err := range []error{ErrInvalidKey, ErrRevoked, ErrExpired, fmt.Errorf("wrapped: %w", ErrRevoked)} {
+		if !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%v: want match with auth.ErrInvalidCredentials (401)", err)
+		}
+	}
+	if !errors.Is(fmt.Errorf("x: %w", ErrExpired), ErrExpired) || errors.Is(ErrExpired, ErrRevoked) {
+		t.Error("credential errors must stay distinguishable")
+	}
+	for _, err := range []error{ErrNotFound, errors.New("connection refused")} {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%v: must not be treated as a credential error", err)
+		}
+	}
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		in     string
+		wantID string
+		wantOK bool
+	}{
+		{in: "mks_0123456789abcdef_secret", wantID: "0123456789abcdef", wantOK: true},
+		{in: "mks_0123456789abcdef_", wantOK: false},
+		{in: "mks_0123456789abcdef", wantOK: false},
+		{in: "mks_0123456789abcdeg_secret", wantOK: false},
+		{in: "mks_ab12cd_secret", wantOK: false},
+		{in: "other_0123456789abcdef_secret", wantOK: false},
+		{in: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		id, ok := parseID(tt.in)
+		if ok != tt.wantOK || (ok && id != tt.wantID) {
+			t.Errorf("parseID(%q) = %q, %v; want %q, %v", tt.in, id, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestClaimTouch(t *testing.T) {
+	s := &Store{}
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if !s.claimTouch("a1", t0) {
+		t.Fatal("first use of a key must be touched")
+	}
+	if s.claimTouch("a1", t0.Add(time.Second)) {
+		t.Error("second touch within resolution")
+	}
+	if !s.claimTouch("b2", t0.Add(time.Second)) {
+		t.Error("other key throttled by a1")
+	}
+	if !s.claimTouch("a1", t0.Add(lastUsedResolution)) {
+		t.Error("no touch after resolution passed")
+	}
+
+	// burst bersamaan: hanya satu yang menjalankan UPDATE
+	var wg sync.WaitGroup
+	var claimed atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.claimTouch("c3", t0) {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := claimed.Load(); n != 1 {
+		t.Errorf("concurrent claims = %d, want 1", n)
+	}
+}
@@ -0,0 +1,230 @@
+// internal/audit/audit.go
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Table: log akses data customer. Append-only: UPDATE/DELETE/TRUNCATE ditolak trigger.
+// Trigger hanya melindungi dari role yang bukan owner: owner tabel bisa men-drop trigger lalu
+// menghapus baris. Supaya bukti akses benar-benar tidak bisa dihapus lewat koneksi aplikasi,
+// tabel harus dimiliki role terpisah dan role aplikasi hanya diberi INSERT/SELECT (lihat
+// CheckOwnership dan README bagian audit).
+const Table = "access_audit"
+
+// Action yang dicatat.
+const (
+	ActionCustomerList    = "customer.list"         // list/search, params = filter
+	ActionProfileView     = "customer.profile_view" // profile 360 (live atau as_of)
+	ActionProfileDiff     = "customer.profile_diff"
+	ActionHistoryView     = "customer.history_view"
+	ActionTimelineView    = "customer.timeline_view"
+	ActionEventsSubscribe = "customer.events_subscribe" // SSE perubahan customer
+	ActionPIIReveal       = "customer.pii_reveal"
+	ActionAuditView       = "audit.view" // reviewer membaca log ini
+)
+
+const (
+	appendOnlyFunction     = Table + "_append_only"
+	appendOnlyRowTrigger   = Table + "_no_update_delete"
+	appendOnlyTruncTrigger = Table + "_no_truncate"
+)
+
+// Entry adalah satu baris audit.
+type Entry struct {
+	ID          int64          `json:"id"`
+	At          time.Time      `json:"at"`
+	Action      string         `json:"action"`
+	Subject     string         `json:"subject"` // "anonymous" kalau auth mati
+	Issuer      string         `json:"issuer,omitempty"`
+	Roles       []string       `json:"roles"`
+	RequestID   string         `json:"request_id"`
+	IP          string         `json:"ip"`
+	Method      string         `json:"method"`
+	Path        string         `json:"path"`
+	Params      map[string]any `json:"params"`
+	CustomerIDs []string       `json:"customer_ids"` // customer yang datanya dikembalikan
+}
+
+// EnsureSchema membuat tabel audit dan trigger append-only kalau belum ada.
+func EnsureSchema(ctx context.Context, db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+			id           BIGSERIAL PRIMARY KEY,
+			at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+			action       TEXT        NOT NULL,
+			subject      TEXT        NOT NULL,
+			issuer       TEXT        NOT NULL DEFAULT '',
+			roles        JSONB       NOT NULL DEFAULT '[]',
+			request_id   TEXT        NOT NULL DEFAULT '',
+			ip           TEXT        NOT NULL DEFAULT '',
+			method       TEXT        NOT NULL DEFAULT '',
+			path         TEXT        NOT NULL DEFAULT '',
+			params       JSONB       NOT NULL DEFAULT '{}',
+			customer_ids JSONB       NOT NULL DEFAULT '[]'
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_at_idx ON ` + Table + ` (at)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_subject_idx ON ` + Table + ` (subject, at)`,
+		`CREATE INDEX IF NOT EXISTS ` + Table + `_customer_idx ON ` + Table + ` USING GIN (customer_ids)`,
+		fmt.Sprintf(`
+			CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION '%s is append-only (%% not allowed)', TG_OP;
+			END;
+			$$ LANGUAGE plpgsql`, appendOnlyFunction, Table),
+		`DROP TRIGGER IF EXISTS ` + appendOnlyRowTrigger + ` ON ` + Table,
+		fmt.Sprintf(`CREATE TRIGGER %s BEFORE UPDATE OR DELETE ON %s
+			FOR EACH ROW EXECUTE FUNCTION %s()`, appendOnlyRowTrigger, Table, appendOnlyFunction),
+		`DROP TRIGGER IF EXISTS ` + appendOnlyTruncTrigger + ` ON ` + Table,
+		fmt.Sprintf(`CREATE TRIGGER %s BEFORE TRUNCATE ON %s
+			FOR EACH STATEMENT EXECUTE FUNCTION %s()`, appendOnlyTruncTrigger, Table, appendOnlyFunction),
+	}
+	for _, q := range stmts {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("ensure audit schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckOwnership mengembalikan owner tabel audit dan apakah role koneksi ini (atau role
+// yang ia warisi) adalah owner-nya, yaitu bisa men-drop trigger append-only.
+// sql.ErrNoRows berarti tabel belum ada.
+func CheckOwnership(ctx context.Context, db *sql.DB) (owner string, ownedByApp bool, err error) {
+	err = db.QueryRowContext(ctx, `
+		SELECT pg_get_userbyid(c.relowner), pg_has_role(current_user, c.relowner, 'MEMBER')
+		FROM pg_class c
+		WHERE c.oid = to_regclass($1)
+	`, Table).Scan(&owner, &ownedByApp)
+	return owner, ownedByApp, err
+}
+
+// Log menulis dan membaca tabel audit.
+type Log struct {
+	DB *sql.DB
+}
+
+// Record menyimpan satu entri. At diisi oleh database.
+func (l *Log) Record(ctx context.Context, e Entry) error {
+	roles, err := json.Marshal(nonNil(e.Roles))
+	if err != nil {
+		return err
+	}
+	if e.Params == nil {
+		e.Params = map[string]any{}
+	}
+	params, err := json.Marshal(e.Params)
+	if err != nil {
+		return err
+	}
+	ids, err := json.Marshal(nonNil(e.CustomerIDs))
+	if err != nil {
+		return err
+	}
+
+	_, err = l.DB.ExecContext(ctx, `
+		INSERT INTO `+Table+` (action, subject, issuer, roles, request_id, ip, method, path, params, customer_ids)
+		VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9::jsonb, $10::jsonb)
+	`, e.Action, e.Subject, e.Issuer, string(roles), e.RequestID, e.IP, e.Method, e.Path, string(params), string(ids))
+	if err != nil {
+		return fmt.Errorf("insert %s: %w", Table, err)
+	}
+	return nil
+}
+
+// Filter untuk Query. Field kosong = tidak difilter.
+type Filter struct {
+	Subject    string
+	Action     string
+	CustomerID string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// Query mengembalikan entri terbaru lebih dulu, beserta total yang cocok dengan filter.
+func (l *Log) Query(ctx context.Context, f Filter) ([]Entry, int, error) {
+	where := []string{}
+	args := []any{}
+	addArg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if f.Subject != "" {
+		where = append(where, "subject = "+addArg(f.Subject))
+	}
+	if f.Action != "" {
+		where = append(where, "action = "+addArg(f.Action))
+	}
+	if f.CustomerID != "" {
+		where = append(where, "customer_ids @> jsonb_build_array("+addArg(f.CustomerID)+"::text)")
+	}
+	if f.RequestID != "" {
+		where = append(where, "request_id = "+addArg(f.RequestID))
+	}
+	if f.From != nil {
+		where = append(where, "at >= "+addArg(*f.From))
+	}
+	if f.To != nil {
+		where = append(where, "at < "+addArg(*f.To))
+	}
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := l.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+Table+` `+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count %s: %w", Table, err)
+	}
+
+	q := fmt.Sprintf(`
+		SELECT id, at, action, subject, issuer, roles, request_id, ip, method, path, params, customer_ids
+		FROM %s
+		%s
+		ORDER BY at DESC, id DESC
+		LIMIT %s OFFSET %s
+	`, Table, whereSQL, addArg(f.Limit), addArg(f.Offset))
+	rows, err := l.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query %s: %w", Table, err)
+	}
+	defer rows.Close()
+
+	out := []Entry{}
+	for rows.Next() {
+		var (
+			e                  Entry
+			roles, params, ids []byte
+		)
+		if err := rows.Scan(&e.ID, &e.At, &e.Action, &e.Subject, &e.Issuer, &roles,
+			&e.RequestID, &e.IP, &e.Method, &e.Path, &params, &ids); err != nil {
+			return nil, 0, fmt.Errorf("scan %s: %w", Table, err)
+		}
+		if err := json.Unmarshal(roles, &e.Roles); err != nil {
+			return nil, 0, fmt.Errorf("decode roles of entry %d: %w", e.ID, err)
+		}
+		if err := json.Unmarshal(params, &e.Params); err != nil {
+			return nil, 0, fmt.Errorf("decode params of entry %d: %w", e.ID, err)
+		}
+		if err := json.Unmarshal(ids, &e.CustomerIDs); err != nil {
+			return nil, 0, fmt.Errorf("decode customer_ids of entry %d: %w", e.ID, err)
+		}
+		out = append(out, e)
+	}
+	return out, total, rows.Err()
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,309 @@
+// internal/auth/jwks.go
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"mini-poc-02/backend/internal/logging"
+)
+
+var ErrKeyNotFound = errors.New("signing key not found in JWKS")
+
+// KeySet memuat JWKS dari file lokal (path atau file:/path) atau URL http(s).
+// Sumber URL di-refresh berkala dan saat token memakai kid yang belum dikenal
+// (rotasi key di IdP), dengan jeda minimal supaya kid palsu tidak memicu fetch terus-menerus.
+// Fetch tidak pernah dilakukan sambil memegang lock: refresh berkala berjalan di background
+// sementara key di cache tetap dipakai, dan fetch bersamaan digabung lewat singleflight.
+type KeySet struct {
+	Source     string
+	Refresh    time.Duration // interval refresh untuk sumber URL
+	HTTPClient *http.Client
+
+	group singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]any // kid -> *rsa.PublicKey | *ecdsa.PublicKey
+	fetchedAt   time.Time      // load terakhir yang berhasil
+	lastAttempt time.Time      // load terakhir (berhasil atau gagal), untuk minRefetchInterval
+}
+
+const minRefetchInterval = 30 * time.Second
+
+func NewKeySet(source string, refresh time.Duration) *KeySet {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	return &KeySet{
+		Source:     source,
+		Refresh:    refresh,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Load memuat JWKS sekarang (dipakai saat startup supaya konfigurasi salah langsung ketahuan).
+func (ks *KeySet) Load(ctx context.Context) error {
+	return ks.reload(ctx)
+}
+
+// Key mengembalikan public key untuk kid. kid kosong diterima kalau JWKS hanya berisi satu key.
+// Request hanya menunggu fetch kalau belum ada key sama sekali, atau kid belum dikenal dan
+// jeda minimal sejak fetch terakhir sudah lewat.
+func (ks *KeySet) Key(ctx context.Context, kid string) (any, error) {
+	ks.mu.RLock()
+	loaded := ks.keys != nil
+	stale := ks.isURL() && time.Since(ks.fetchedAt) > ks.Refresh
+	ks.mu.RUnlock()
+
+	switch {
+	case !loaded:
+		if err := ks.reload(ctx); err != nil {
+			return nil, err
+		}
+	case stale && ks.claimRefetch():
+		// stale-while-revalidate: key lama tetap dipakai sampai refresh selesai
+		go func() {
+			if err := ks.reload(context.WithoutCancel(ctx)); err != nil {
+				logging.FromContext(ctx).Warn("auth: JWKS refresh failed, keeping cached keys", "error", err)
+			}
+		}()
+	}
+
+	if k, ok := ks.lookup(kid); ok {
+		return k, nil
+	}
+
+	if ks.isURL() && ks.claimRefetch() {
+		if err := ks.reload(ctx); err != nil {
+			return nil, err
+		}
+		if k, ok := ks.lookup(kid); ok {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w (kid=%q)", ErrKeyNotFound, kid)
+}
+
+// claimRefetch true kalau jeda minimal sejak fetch terakhir sudah lewat, sekaligus mencatat
+// percobaan baru supaya request lain tidak ikut memicu fetch.
+func (ks *KeySet) claimRefetch() bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if time.Since(ks.lastAttempt) <= minRefetchInterval {
+		return false
+	}
+	ks.lastAttempt = time.Now()
+	return true
+}
+
+func (ks *KeySet) lookup(kid string) (any, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, true
+		}
+	}
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+func (ks *KeySet) isURL() bool {
+	return strings.HasPrefix(ks.Source, "http://") || strings.HasPrefix(ks.Source, "https://")
+}
+
+// reload memuat ulang JWKS; pemanggil bersamaan berbagi satu fetch. Menunggu hasil bisa
+// dibatalkan lewat ctx tanpa membatalkan fetch milik pemanggil lain.
+func (ks *KeySet) reload(ctx context.Context) error {
+	ch := ks.group.DoChan("jwks", func() (any, error) {
+		ks.mu.Lock()
+		ks.lastAttempt = time.Now()
+		ks.mu.Unlock()
+
+		// timeout dari HTTPClient; request yang memicu fetch boleh selesai lebih dulu
+		keys, err := ks.load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		ks.mu.Lock()
+		ks.keys = keys
+		ks.fetchedAt = time.Now()
+		ks.mu.Unlock()
+		return nil, nil
+	})
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ks *KeySet) load(ctx context.Context) (map[string]any, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if ks.isURL() {
+		raw, err = ks.fetch(ctx, ks.Source)
+	} else {
+		raw, err = os.ReadFile(strings.TrimPrefix(ks.Source, "file:"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load JWKS %s: %w", ks.Source, err)
+	}
+
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse JWKS %s: %w", ks.Source, err)
+	}
+	return keys, nil
+}
+
+func (ks *KeySet) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ks.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS mem-parse dokumen JWKS ({"keys": [...]}). Hanya key RSA dan EC (P-256/384/521)
+// untuk signature yang dipakai; key lain (mis. use=enc) dilewati.
+func ParseJWKS(raw []byte) (map[string]any, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]any{}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			pub any
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			pub, err = rsaKey(k)
+		case "EC":
+			pub, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := b64Int(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := b64Int(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := b64Int(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := b64Int(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// DiscoverJWKS membaca jwks_uri dari <issuer>/.well-known/openid-configuration.
+func DiscoverJWKS(ctx context.Context, issuer string) (string, error) {
+	ks := NewKeySet("", 0)
+	raw, err := ks.fetch(ctx, strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return "", fmt.Errorf("OIDC discovery: %w", err)
+	}
+	var doc struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "", fmt.Errorf("OIDC discovery: %w", err)
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("OIDC discovery: jwks_uri is empty")
+	}
+	return doc.JWKSURI, nil
+}
@@ -0,0 +1,46 @@
+// internal/auth/principal.go
+package auth
+
+import "context"
+
+// Principal adalah identitas hasil verifikasi token.
+type Principal struct {
+	Subject string         `json:"sub"`
+	Issuer  string         `json:"iss"`
+	Email   string         `json:"email,omitempty"`
+	Name    string         `json:"name,omitempty"`
+	Roles   []string       `json:"roles"`
+	Claims  map[string]any `json:"-"` // semua claim mentah (mis. province / city untuk RBAC)
+
+	// Scopes diisi untuk API key: permission langsung (tanpa role), lihat rbac.Enforcer.Decide.
+	Scopes []string `json:"scopes,omitempty"`
+	// RowScopes (API key): batasan baris per permission, diwarisi dari pembuat key. Tiap map
+	// adalah syarat kolom = nilai; permission tanpa entri = tanpa batasan baris.
+	RowScopes map[string][]map[string]string `json:"-"`
+}
+
+// HasRole mengecek apakah principal punya role tertentu.
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal menyimpan principal di context request.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom mengambil principal dari context (nil kalau request tidak terautentikasi).
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
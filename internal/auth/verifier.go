@@ -0,0 +1,244 @@
+// internal/auth/verifier.go
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"mini-poc-02/backend/internal/logging"
+)
+
+// Config mengatur verifikasi bearer JWT.
+type Config struct {
+	Issuer     string        // wajib sama dengan claim iss
+	Audience   string        // wajib ada di claim aud
+	RolesClaim string        // path claim role, mis. "roles" atau "realm_access.roles" (Keycloak)
+	Leeway     time.Duration // toleransi clock skew untuk exp/nbf/iat
+	Public     []string      // path yang tidak butuh token (exact match)
+
+	// QueryToken: pola path stream SSE yang boleh membawa token di ?access_token=
+	// (EventSource tidak bisa set header). Segmen {param} cocok dengan satu segmen apa saja.
+	QueryToken []string
+}
+
+// ErrInvalidCredentials: kredensial ditolak (key salah, dicabut, kedaluwarsa). Error dari
+// APIKeyAuthenticator yang cocok dengan ini (errors.Is) dijawab 401; error lain dianggap
+// gangguan backend dan dijawab 503 tanpa detail.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// APIKeyAuthenticator memverifikasi header X-API-Key (lihat package apikey).
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*Principal, error)
+}
+
+// Verifier memvalidasi bearer JWT terhadap issuer, audience dan JWKS.
+type Verifier struct {
+	cfg  Config
+	keys *KeySet
+
+	// APIKeys opsional: kalau di-set, header X-API-Key diterima sebagai alternatif JWT.
+	APIKeys APIKeyAuthenticator
+}
+
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+func NewVerifier(keys *KeySet, cfg Config) (*Verifier, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("auth: issuer and audience are required")
+	}
+	if keys == nil {
+		return nil, errors.New("auth: JWKS is required")
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	return &Verifier{cfg: cfg, keys: keys}, nil
+}
+
+// Verify mem-parse dan memvalidasi token, lalu membangun Principal dari claim-nya.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return v.keys.Key(ctx, kid)
+		},
+		jwt.WithValidMethods(validMethods),
+		jwt.WithIssuer(v.cfg.Issuer),
+		jwt.WithAudience(v.cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(v.cfg.Leeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Principal{Claims: claims, Roles: []string{}}
+	p.Subject, _ = claims["sub"].(string)
+	p.Issuer, _ = claims["iss"].(string)
+	p.Email, _ = claims["email"].(string)
+	p.Name, _ = claims["name"].(string)
+	if p.Subject == "" {
+		return nil, errors.New("token has no sub claim")
+	}
+	p.Roles = stringList(lookupClaim(claims, v.cfg.RolesClaim))
+	return p, nil
+}
+
+// Middleware mewajibkan bearer token (atau X-API-Key) untuk semua path kecuali Config.Public.
+// Verifier nil = autentikasi dimatikan.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	if v == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v.isPublic(r.URL.Path) || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if key := r.Header.Get("X-API-Key"); key != "" && v.APIKeys != nil {
+			p, err := v.APIKeys.Authenticate(r.Context(), key)
+			if errors.Is(err, ErrInvalidCredentials) {
+				unauthorized(w, "invalid_api_key", err.Error())
+				return
+			}
+			if err != nil {
+				logging.FromContext(r.Context()).Error("auth: API key lookup failed", "error", err)
+				unavailable(w)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+			return
+		}
+
+		token, r := v.bearerToken(r)
+		if token == "" {
+			unauthorized(w, "invalid_request", "missing bearer token")
+			return
+		}
+		p, err := v.Verify(r.Context(), token)
+		if err != nil {
+			unauthorized(w, "invalid_token", err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+func (v *Verifier) isPublic(path string) bool {
+	for _, p := range v.cfg.Public {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// bearerToken membaca header Authorization. EventSource di browser tidak bisa mengirim
+// header, jadi request SSE ke path Config.QueryToken boleh memakai query access_token;
+// token itu dibuang dari URL yang diteruskan supaya tidak ikut tercatat di log/trace.
+func (v *Verifier) bearerToken(r *http.Request) (string, *http.Request) {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:]), r
+	}
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") || !v.allowsQueryToken(r.URL.Path) {
+		return "", r
+	}
+	q := r.URL.Query()
+	token := q.Get("access_token")
+	if token == "" {
+		return "", r
+	}
+	q.Del("access_token")
+	r2 := r.Clone(r.Context())
+	r2.URL.RawQuery = q.Encode()
+	r2.RequestURI = r2.URL.RequestURI()
+	return token, r2
+}
+
+func (v *Verifier) allowsQueryToken(path string) bool {
+	for _, p := range v.cfg.QueryToken {
+		if matchPath(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath mencocokkan path dengan pola per segmen; segmen "{...}" cocok dengan segmen
+// tidak kosong apa saja.
+func matchPath(pattern, path string) bool {
+	ps, xs := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(ps) != len(xs) {
+		return false
+	}
+	for i, seg := range ps {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if xs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != xs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func unauthorized(w http.ResponseWriter, code, desc string) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error=%q, error_description=%q`, code, desc))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": "unauthorized", "code": code, "details": desc})
+}
+
+func unavailable(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Retry-After", "5")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": "authentication is temporarily unavailable"})
+}
+
+// lookupClaim mengikuti path bertitik, mis. "realm_access.roles".
+func lookupClaim(claims map[string]any, path string) any {
+	var cur any = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// stringList menerima array string atau string dipisah spasi/koma (mis. claim "scope").
+func stringList(v any) []string {
+	out := []string{}
+	switch x := v.(type) {
+	case []any:
+		for _, e := range x {
+			if s, ok := e.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	case string:
+		for _, s := range strings.FieldsFunc(x, func(r rune) bool { return r == ' ' || r == ',' }) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
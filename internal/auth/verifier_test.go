@@ -0,0 +1,579 @@
+// internal/auth/verifier_test.go
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.example/realms/mks"
+	testAudience = "mks-backend"
+)
+
+type testKey struct {
+	kid  string
+	priv *rsa.PrivateKey
+}
+
+func newTestKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, priv: priv}
+}
+
+func (k testKey) jwk() map[string]any {
+	return map[string]any{
+		"kty": "RSA",
+		"kid": k.kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(k.priv.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.priv.E)).Bytes()),
+	}
+}
+
+func jwksJSON(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	list := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, k.jwk())
+	}
+	raw, err := json.Marshal(map[string]any{"keys": list})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// writeJWKS menulis JWKS ke file sementara (sumber lokal, tanpa network).
+func writeJWKS(t *testing.T, keys ...testKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksJSON(t, keys...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"email": "ops@example.com",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"realm_access": map[string]any{
+			"roles": []any{"ops", "viewer"},
+		},
+	}
+}
+
+func sign(t *testing.T, k testKey, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if k.kid != "" {
+		tok.Header["kid"] = k.kid
+	}
+	s, err := tok.SignedString(k.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestVerifier(t *testing.T, source string) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(NewKeySet(source, time.Hour), Config{
+		Issuer:     testIssuer,
+		Audience:   testAudience,
+		RolesClaim: "realm_access.roles",
+		Leeway:     30 * time.Second,
+		Public:     []string{"/api/v1/health"},
+		QueryToken: []string{"/api/v1/stream/dashboard", "/api/v1/customers/{customer_id}/events"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	key := newTestKey(t, "k1")
+	other := newTestKey(t, "k1") // kid sama, key berbeda (signature tidak cocok)
+	v := newTestVerifier(t, writeJWKS(t, key))
+
+	with := func(mod func(c jwt.MapClaims)) jwt.MapClaims {
+		c := validClaims()
+		mod(c)
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error // nil = token valid
+	}{
+		{name: "valid", token: sign(t, key, validClaims())},
+		{name: "audience list", token: sign(t, key, with(func(c jwt.MapClaims) { c["aud"] = []any{"other", testAudience} }))},
+		{name: "expired within leeway", token: sign(t, key, with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() }))},
+		{name: "wrong issuer", token: sign(t, key, with(func(c jwt.MapClaims) { c["iss"] = "https://evil.example" })), wantErr: jwt.ErrTokenInvalidIssuer},
+		{name: "missing issuer", token: sign(t, key, with(func(c jwt.MapClaims) { delete(c, "iss") })), wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "wrong audience", token: sign(t, key, with(func(c jwt.MapClaims) { c["aud"] = "other-api" })), wantErr: jwt.ErrTokenInvalidAudience},
+		{name: "expired", token: sign(t, key, with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })), wantErr: jwt.ErrTokenExpired},
+		{name: "missing exp", token: sign(t, key, with(func(c jwt.MapClaims) { delete(c, "exp") })), wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "not yet valid", token: sign(t, key, with(func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() })), wantErr: jwt.ErrTokenNotValidYet},
+		{name: "issued in the future", token: sign(t, key, with(func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() })), wantErr: jwt.ErrTokenUsedBeforeIssued},
+		{name: "unknown kid", token: sign(t, testKey{kid: "k9", priv: key.priv}, validClaims()), wantErr: ErrKeyNotFound},
+		{name: "bad signature", token: sign(t, other, validClaims()), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "missing sub", token: sign(t, key, with(func(c jwt.MapClaims) { delete(c, "sub") })), wantErr: errAny},
+		{name: "malformed", token: "not-a-jwt", wantErr: jwt.ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tt.token)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("Verify: unexpected error %v", err)
+			case tt.wantErr == nil:
+				if p.Subject != "user-1" || p.Issuer != testIssuer || p.Email != "ops@example.com" {
+					t.Errorf("principal = %+v", p)
+				}
+				if !p.HasRole("ops") || !p.HasRole("viewer") {
+					t.Errorf("roles = %v, want ops and viewer", p.Roles)
+				}
+			case err == nil:
+				t.Fatalf("Verify: want error %v, got principal %+v", tt.wantErr, p)
+			case tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Verify: error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errAny: cukup ada error, tanpa jenis tertentu.
+var errAny = errors.New("any error")
+
+func TestVerifyRejectsUnexpectedAlgorithms(t *testing.T) {
+	key := newTestKey(t, "k1")
+	v := newTestVerifier(t, writeJWKS(t, key))
+
+	// HS256 dengan modulus publik sebagai secret (serangan key confusion) dan alg none
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	hs.Header["kid"] = "k1"
+	hsToken, err := hs.SignedString(key.priv.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+	noneToken, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tok := range map[string]string{"HS256": hsToken, "none": noneToken} {
+		if _, err := v.Verify(context.Background(), tok); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			t.Errorf("%s: error = %v, want %v", name, err, jwt.ErrTokenSignatureInvalid)
+		}
+	}
+}
+
+func TestVerifyECKeyWithoutKid(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := func(b []byte) string {
+		out := make([]byte, 32)
+		copy(out[32-len(b):], b)
+		return base64.RawURLEncoding.EncodeToString(out)
+	}
+	raw, _ := json.Marshal(map[string]any{"keys": []any{map[string]any{
+		"kty": "EC", "crv": "P-256", "x": pad(priv.X.Bytes()), "y": pad(priv.Y.Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v := newTestVerifier(t, "file:"+path)
+
+	// JWKS berisi satu key: token tanpa kid tetap diterima
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodES256, validClaims()).SignedString(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(context.Background(), tok); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+// jwksServer adalah IdP palsu yang JWKS-nya bisa diganti (rotasi key).
+type jwksServer struct {
+	*httptest.Server
+	hits atomic.Int32
+
+	mu   sync.Mutex
+	body []byte
+}
+
+func newJWKSServer(t *testing.T, keys ...testKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{body: jwksJSON(t, keys...)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) rotate(t *testing.T, keys ...testKey) {
+	s.mu.Lock()
+	s.body = jwksJSON(t, keys...)
+	s.mu.Unlock()
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := newTestKey(t, "2024-01"), newTestKey(t, "2024-07")
+	srv := newJWKSServer(t, oldKey)
+	v := newTestVerifier(t, srv.URL+"/certs")
+	v.keys.HTTPClient = srv.Client()
+	ctx := context.Background()
+
+	if _, err := v.Verify(ctx, sign(t, oldKey, validClaims())); err != nil {
+		t.Fatalf("old key before rotation: %v", err)
+	}
+
+	// IdP merotasi key. Dalam minRefetchInterval, kid baru belum memicu fetch ulang.
+	srv.rotate(t, oldKey, newKey)
+	if _, err := v.Verify(ctx, sign(t, newKey, validClaims())); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("new kid inside refetch interval: error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if got := srv.hits.Load(); got != 1 {
+		t.Fatalf("JWKS fetches = %d, want 1", got)
+	}
+
+	// setelah jeda minimal lewat, kid yang belum dikenal memicu fetch ulang
+	v.keys.mu.Lock()
+	v.keys.lastAttempt = time.Now().Add(-minRefetchInterval - time.Second)
+	v.keys.mu.Unlock()
+	if _, err := v.Verify(ctx, sign(t, newKey, validClaims())); err != nil {
+		t.Fatalf("new kid after refetch: %v", err)
+	}
+	if got := srv.hits.Load(); got != 2 {
+		t.Fatalf("JWKS fetches = %d, want 2", got)
+	}
+	if _, err := v.Verify(ctx, sign(t, oldKey, validClaims())); err != nil {
+		t.Fatalf("old key still published: %v", err)
+	}
+
+	// key lama dicabut dan cache sudah melewati Refresh: request berikutnya masih dilayani
+	// dari cache sementara refresh berjalan di background, setelah itu token lama ditolak
+	srv.rotate(t, newKey)
+	v.keys.mu.Lock()
+	v.keys.fetchedAt = time.Now().Add(-2 * time.Hour)
+	v.keys.lastAttempt = v.keys.fetchedAt
+	v.keys.mu.Unlock()
+	if _, err := v.Verify(ctx, sign(t, oldKey, validClaims())); err != nil {
+		t.Fatalf("retired kid while refresh is pending: %v", err)
+	}
+	waitFor(t, func() bool {
+		v.keys.mu.RLock()
+		defer v.keys.mu.RUnlock()
+		return time.Since(v.keys.fetchedAt) < time.Minute
+	})
+	if _, err := v.Verify(ctx, sign(t, oldKey, validClaims())); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("retired kid: error = %v, want %v", err, ErrKeyNotFound)
+	}
+	if got := srv.hits.Load(); got != 3 {
+		t.Fatalf("JWKS fetches = %d, want 3", got)
+	}
+}
+
+func TestKeySetRefreshDoesNotBlockCachedKeys(t *testing.T) {
+	key, unknown := newTestKey(t, "k1"), newTestKey(t, "k2")
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) > 1 {
+			<-release // IdP lambat saat refresh
+		}
+		_, _ = w.Write(jwksJSON(t, key))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ks := NewKeySet(srv.URL, time.Minute)
+	ks.HTTPClient = srv.Client()
+	if err := ks.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ks.mu.Lock()
+	ks.fetchedAt = time.Now().Add(-2 * time.Minute)
+	ks.lastAttempt = ks.fetchedAt
+	ks.mu.Unlock()
+
+	// banyak request bersamaan dengan kid yang dikenal: semua dilayani dari cache
+	done := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		go func() {
+			_, err := ks.Key(context.Background(), "k1")
+			done <- err
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Key blocked behind a pending JWKS refresh")
+		}
+	}
+
+	// kid tak dikenal di dalam jeda minimal: langsung ditolak, tidak menunggu fetch
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := ks.Key(ctx, unknown.kid); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("unknown kid: error = %v, want %v", err, ErrKeyNotFound)
+	}
+	waitFor(t, func() bool { return hits.Load() == 2 })
+}
+
+// waitFor menunggu cond terpenuhi (mis. hasil goroutine background), maksimal 2 detik.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 2s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestKeySetKeepsKeysWhenRefreshFails(t *testing.T) {
+	key := newTestKey(t, "k1")
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(jwksJSON(t, key))
+	}))
+	defer srv.Close()
+
+	ks := NewKeySet(srv.URL, time.Minute)
+	ks.HTTPClient = srv.Client()
+	if err := ks.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// IdP sedang down saat refresh berkala: key yang sudah dimuat tetap dipakai
+	fail.Store(true)
+	ks.mu.Lock()
+	ks.fetchedAt = time.Now().Add(-2 * time.Minute)
+	ks.lastAttempt = ks.fetchedAt
+	ks.mu.Unlock()
+	if _, err := ks.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Key during IdP outage: %v", err)
+	}
+}
+
+func TestDiscoverJWKS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/mks/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"issuer":"x","jwks_uri":"https://idp.example/certs"}`))
+	}))
+	defer srv.Close()
+
+	got, err := DiscoverJWKS(context.Background(), srv.URL+"/realms/mks/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "https://idp.example/certs" {
+		t.Errorf("jwks_uri = %q", got)
+	}
+	if _, err := DiscoverJWKS(context.Background(), srv.URL+"/other"); err == nil {
+		t.Error("want error for missing discovery document")
+	}
+}
+
+// fakeAPIKeys memetakan key ke principal; "mks_dbdown" meniru DB yang tidak bisa dihubungi,
+// key lain ditolak.
+type fakeAPIKeys map[string]*Principal
+
+func (f fakeAPIKeys) Authenticate(_ context.Context, key string) (*Principal, error) {
+	if p, ok := f[key]; ok {
+		return p, nil
+	}
+	if key == "mks_dbdown" {
+		return nil, errors.New("dial tcp 10.0.0.7:5432: connect: connection refused")
+	}
+	return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+}
+
+func TestMiddleware(t *testing.T) {
+	key := newTestKey(t, "k1")
+	v := newTestVerifier(t, writeJWKS(t, key))
+	v.APIKeys = fakeAPIKeys{"mks_good": {Subject: "apikey:1", Scopes: []string{"stats:read"}}}
+	valid := sign(t, key, validClaims())
+
+	var got *Principal
+	h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = PrincipalFrom(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   map[string]string
+		wantCode int
+		wantSub  string
+		wantErr  string // error=... di WWW-Authenticate
+	}{
+		{name: "public health", path: "/api/v1/health", wantCode: http.StatusNoContent},
+		{name: "preflight", method: http.MethodOptions, path: "/api/v1/customers", wantCode: http.StatusNoContent},
+		{name: "missing token", path: "/api/v1/customers", wantCode: http.StatusUnauthorized, wantErr: "invalid_request"},
+		{name: "bearer", path: "/api/v1/customers", header: map[string]string{"Authorization": "Bearer " + valid}, wantCode: http.StatusNoContent, wantSub: "user-1"},
+		{name: "bearer lowercase scheme", path: "/api/v1/customers", header: map[string]string{"Authorization": "bearer " + valid}, wantCode: http.StatusNoContent, wantSub: "user-1"},
+		{name: "invalid token", path: "/api/v1/customers", header: map[string]string{"Authorization": "Bearer nope"}, wantCode: http.StatusUnauthorized, wantErr: "invalid_token"},
+		{name: "query token ignored for JSON", path: "/api/v1/customers?access_token=" + valid, wantCode: http.StatusUnauthorized, wantErr: "invalid_request"},
+		{name: "query token for SSE", path: "/api/v1/stream/dashboard?access_token=" + valid, header: map[string]string{"Accept": "text/event-stream"}, wantCode: http.StatusNoContent, wantSub: "user-1"},
+		{name: "query token for customer events", path: "/api/v1/customers/C1/events?access_token=" + valid, header: map[string]string{"Accept": "text/event-stream"}, wantCode: http.StatusNoContent, wantSub: "user-1"},
+		{name: "query token with SSE accept on other path", path: "/api/v1/customers?access_token=" + valid, header: map[string]string{"Accept": "text/event-stream"}, wantCode: http.StatusUnauthorized, wantErr: "invalid_request"},
+		{name: "query token on nested path", path: "/api/v1/customers/C1/events/x?access_token=" + valid, header: map[string]string{"Accept": "text/event-stream"}, wantCode: http.StatusUnauthorized, wantErr: "invalid_request"},
+		{name: "api key", path: "/api/v1/stats/kpi", header: map[string]string{"X-API-Key": "mks_good"}, wantCode: http.StatusNoContent, wantSub: "apikey:1"},
+		{name: "bad api key", path: "/api/v1/stats/kpi", header: map[string]string{"X-API-Key": "mks_bad"}, wantCode: http.StatusUnauthorized, wantErr: "invalid_api_key"},
+		{name: "api key store down", path: "/api/v1/stats/kpi", header: map[string]string{"X-API-Key": "mks_dbdown"}, wantCode: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			for k, val := range tt.header {
+				req.Header.Set(k, val)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantCode, rec.Body)
+			}
+			if strings.Contains(rec.Body.String(), "10.0.0.7") {
+				t.Errorf("response leaks backend error: %s", rec.Body)
+			}
+			if tt.wantErr != "" {
+				if wa := rec.Header().Get("WWW-Authenticate"); !strings.Contains(wa, `error="`+tt.wantErr+`"`) {
+					t.Errorf("WWW-Authenticate = %q, want error %q", wa, tt.wantErr)
+				}
+			}
+			switch {
+			case tt.wantSub == "" && got != nil:
+				t.Errorf("principal = %+v, want none", got)
+			case tt.wantSub != "" && (got == nil || got.Subject != tt.wantSub):
+				t.Errorf("principal = %+v, want sub %q", got, tt.wantSub)
+			}
+		})
+	}
+}
+
+func TestMiddlewareStripsQueryToken(t *testing.T) {
+	key := newTestKey(t, "k1")
+	v := newTestVerifier(t, writeJWKS(t, key))
+	valid := sign(t, key, validClaims())
+
+	var gotURL, gotRequestURI string
+	h := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL, gotRequestURI = r.URL.String(), r.RequestURI
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stream/dashboard?last_event_id=7&access_token="+valid, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotURL != "/api/v1/stream/dashboard?last_event_id=7" || gotRequestURI != gotURL {
+		t.Errorf("URL = %q, RequestURI = %q, want access_token removed", gotURL, gotRequestURI)
+	}
+	if !strings.Contains(req.URL.RawQuery, "access_token") {
+		t.Error("original request mutated")
+	}
+}
+
+func TestNilVerifierMiddleware(t *testing.T) {
+	var v *Verifier
+	rec := httptest.NewRecorder()
+	v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/customers", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestNewVerifierRequiresIssuerAndAudience(t *testing.T) {
+	ks := NewKeySet("jwks.json", 0)
+	for _, cfg := range []Config{{Audience: testAudience}, {Issuer: testIssuer}} {
+		if _, err := NewVerifier(ks, cfg); err == nil {
+			t.Errorf("NewVerifier(%+v): want error", cfg)
+		}
+	}
+	if _, err := NewVerifier(nil, Config{Issuer: testIssuer, Audience: testAudience}); err == nil {
+		t.Error("NewVerifier without JWKS: want error")
+	}
+}
+
+func TestParseJWKSSkipsEncryptionKeys(t *testing.T) {
+	key := newTestKey(t, "sig")
+	enc := newTestKey(t, "enc").jwk()
+	enc["use"] = "enc"
+	raw, _ := json.Marshal(map[string]any{"keys": []any{key.jwk(), enc, map[string]any{"kty": "oct", "kid": "hmac"}}})
+
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keys["sig"]; !ok || len(keys) != 1 {
+		t.Fatalf("keys = %v, want only sig", keys)
+	}
+	if _, err := ParseJWKS([]byte(`{"keys":[]}`)); err == nil {
+		t.Error("empty JWKS: want error")
+	}
+}
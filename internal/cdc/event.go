@@ -0,0 +1,134 @@
+// internal/cdc/event.go
+package cdc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Op Debezium: c = create, u = update, d = delete, r = read (snapshot), t = truncate.
+const (
+	OpCreate   = "c"
+	OpUpdate   = "u"
+	OpDelete   = "d"
+	OpRead     = "r"
+	OpTruncate = "t"
+)
+
+// ErrTombstone dikembalikan untuk record tanpa value (tombstone setelah delete).
+var ErrTombstone = errors.New("tombstone record")
+
+// SourceInfo adalah subset blok "source" Debezium MySQL.
+type SourceInfo struct {
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	DB        string `json:"db"`
+	Table     string `json:"table"`
+	TsMs      int64  `json:"ts_ms"`
+	Snapshot  any    `json:"snapshot"` // "true" | "last" | "false" | bool, tergantung versi
+}
+
+// Envelope adalah struktur value Debezium (setelah unwrap "payload" kalau schema aktif).
+type Envelope struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+	Op     string          `json:"op"`
+	Source SourceInfo      `json:"source"`
+	TsMs   int64           `json:"ts_ms"`
+}
+
+// Event adalah envelope yang sudah di-parse plus waktu-waktu yang relevan untuk lag.
+type Event struct {
+	Envelope
+
+	Table      string    // source.table
+	SourceTime time.Time // source.ts_ms: waktu commit di MySQL
+	SinkTime   time.Time // waktu record sampai di sisi sink (timestamp message, atau ts_ms envelope)
+}
+
+// Latency = waktu sampai di sink - waktu commit di source.
+func (e Event) Latency() time.Duration {
+	return e.SinkTime.Sub(e.SourceTime)
+}
+
+// IsSnapshot true untuk event hasil initial snapshot (bukan perubahan real-time).
+func (e Event) IsSnapshot() bool {
+	if e.Op == OpRead {
+		return true
+	}
+	switch v := e.Source.Snapshot.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "last"
+	}
+	return false
+}
+
+// BeforeMap / AfterMap men-decode kolom before/after (nil kalau tidak ada).
+func (e Event) BeforeMap() (map[string]any, error) { return decodeRow(e.Before) }
+func (e Event) AfterMap() (map[string]any, error)  { return decodeRow(e.After) }
+
+func decodeRow(raw json.RawMessage) (map[string]any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	// UseNumber: angka besar (id, nominal) tidak boleh kehilangan presisi lewat float64
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Parse mem-parse value Debezium JSON, dengan atau tanpa wrapper {"schema":..,"payload":..}.
+// receivedAt (boleh zero) dipakai sebagai SinkTime; kalau zero dipakai ts_ms envelope (waktu
+// connector memproses event), bukan jam ingest: replay file/stdin dari dump lama akan
+// menghasilkan lag palsu sebesar umur dump. Tanpa keduanya latency dianggap 0.
+func Parse(value []byte, receivedAt time.Time) (Event, error) {
+	var ev Event
+	if len(value) == 0 || string(value) == "null" {
+		return ev, ErrTombstone
+	}
+
+	var wrapped struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(value, &wrapped); err != nil {
+		return ev, fmt.Errorf("decode debezium message: %w", err)
+	}
+	body := value
+	if len(wrapped.Payload) > 0 {
+		if string(wrapped.Payload) == "null" {
+			return ev, ErrTombstone
+		}
+		body = wrapped.Payload
+	}
+
+	if err := json.Unmarshal(body, &ev.Envelope); err != nil {
+		return ev, fmt.Errorf("decode debezium envelope: %w", err)
+	}
+	if ev.Op == "" {
+		return ev, errors.New("debezium envelope without op")
+	}
+	if ev.Source.TsMs == 0 {
+		return ev, errors.New("debezium envelope without source.ts_ms")
+	}
+
+	ev.Table = ev.Source.Table
+	ev.SourceTime = time.UnixMilli(ev.Source.TsMs).UTC()
+	switch {
+	case !receivedAt.IsZero():
+		ev.SinkTime = receivedAt.UTC()
+	case ev.TsMs != 0:
+		ev.SinkTime = time.UnixMilli(ev.TsMs).UTC()
+	default:
+		ev.SinkTime = ev.SourceTime
+	}
+	return ev, nil
+}
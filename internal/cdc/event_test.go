@@ -0,0 +1,99 @@
+// internal/cdc/event_test.go
+package cdc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	kafkaTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	sourceTime := time.UnixMilli(1_700_000_000_000).UTC()
+	envelopeTime := time.UnixMilli(1_700_000_001_500).UTC()
+
+	tests := []struct {
+		name       string
+		value      string
+		receivedAt time.Time
+		wantErr    error // nil = sukses; errAny = error apa saja
+		wantOp     string
+		wantSink   time.Time
+		snapshot   bool
+	}{
+		{
+			name:     "create uses envelope ts_ms without message timestamp",
+			value:    `{"op":"c","after":{"id":1},"source":{"db":"mks","table":"customers","ts_ms":1700000000000},"ts_ms":1700000001500}`,
+			wantOp:   OpCreate,
+			wantSink: envelopeTime,
+		},
+		{
+			name:       "update uses message timestamp",
+			value:      `{"op":"u","before":{"id":1},"after":{"id":1},"source":{"table":"customers","ts_ms":1700000000000},"ts_ms":1700000001500}`,
+			receivedAt: kafkaTime,
+			wantOp:     OpUpdate,
+			wantSink:   kafkaTime,
+		},
+		{
+			name:     "delete wrapped in schema payload",
+			value:    `{"schema":{},"payload":{"op":"d","before":{"id":1},"source":{"table":"customers","ts_ms":1700000000000},"ts_ms":1700000001500}}`,
+			wantOp:   OpDelete,
+			wantSink: envelopeTime,
+		},
+		{
+			name:     "no timestamps at all gives zero latency",
+			value:    `{"op":"c","after":{"id":1},"source":{"table":"customers","ts_ms":1700000000000}}`,
+			wantOp:   OpCreate,
+			wantSink: sourceTime,
+		},
+		{
+			name:     "snapshot read",
+			value:    `{"op":"r","after":{"id":1},"source":{"table":"customers","ts_ms":1700000000000,"snapshot":"true"},"ts_ms":1700000001500}`,
+			wantOp:   OpRead,
+			wantSink: envelopeTime,
+			snapshot: true,
+		},
+		{name: "tombstone empty", value: ``, wantErr: ErrTombstone},
+		{name: "tombstone null", value: `null`, wantErr: ErrTombstone},
+		{name: "tombstone null payload", value: `{"schema":{},"payload":null}`, wantErr: ErrTombstone},
+		{name: "missing source ts_ms", value: `{"op":"c","after":{"id":1},"source":{"table":"customers"},"ts_ms":1700000001500}`, wantErr: errAny},
+		{name: "missing op", value: `{"after":{"id":1},"source":{"table":"customers","ts_ms":1700000000000}}`, wantErr: errAny},
+		{name: "not json", value: `{`, wantErr: errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := Parse([]byte(tt.value), tt.receivedAt)
+			switch {
+			case tt.wantErr == errAny:
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+			if ev.Op != tt.wantOp {
+				t.Errorf("op = %q, want %q", ev.Op, tt.wantOp)
+			}
+			if ev.Table != "customers" {
+				t.Errorf("table = %q", ev.Table)
+			}
+			if !ev.SourceTime.Equal(sourceTime) {
+				t.Errorf("source time = %v, want %v", ev.SourceTime, sourceTime)
+			}
+			if !ev.SinkTime.Equal(tt.wantSink) {
+				t.Errorf("sink time = %v, want %v", ev.SinkTime, tt.wantSink)
+			}
+			if ev.IsSnapshot() != tt.snapshot {
+				t.Errorf("snapshot = %v, want %v", ev.IsSnapshot(), tt.snapshot)
+			}
+		})
+	}
+}
+
+var errAny = errors.New("any error")
@@ -0,0 +1,263 @@
+// internal/cdc/ingest.go
+package cdc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TableLag adalah agregat latency satu tabel dalam satu window flush.
+type TableLag struct {
+	Table          string    `json:"table"`
+	SourceName     string    `json:"source_name"` // mis. mysql.mks_finance_dw.customers
+	Events         int       `json:"events"`
+	Snapshots      int       `json:"snapshots"` // event snapshot tidak dihitung ke latency
+	P50Ms          int64     `json:"p50_ms"`
+	P95Ms          int64     `json:"p95_ms"`
+	MaxMs          int64     `json:"max_ms"`
+	LastSourceTime time.Time `json:"last_source_ts"`
+	LastSinkTime   time.Time `json:"last_sink_ts"`
+}
+
+// AuditWriter menulis hasil agregasi (biasanya ke sync_audit).
+type AuditWriter interface {
+	WriteLag(ctx context.Context, lags []TableLag, flushedAt time.Time) error
+}
+
+// IngestorConfig mengatur ingestor event Debezium.
+type IngestorConfig struct {
+	FlushInterval time.Duration // jarak antar penulisan agregat
+}
+
+// Ingestor membaca event Debezium dari Source, menghitung latency source->sink per tabel,
+// dan menulis agregatnya secara periodik lewat AuditWriter.
+type Ingestor struct {
+	Source Source
+	Writer AuditWriter
+	cfg    IngestorConfig
+
+	// OnEvent opsional: dipanggil untuk tiap event yang valid (mis. untuk histori perubahan).
+	OnEvent func(ctx context.Context, ev Event) error
+
+	mu     sync.Mutex
+	window map[string]*lagWindow
+}
+
+type lagWindow struct {
+	sourceName string
+	lat        []int64
+	snapshots  int
+	lastSource time.Time
+	lastSink   time.Time
+}
+
+func NewIngestor(src Source, w AuditWriter, cfg IngestorConfig) *Ingestor {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 10 * time.Second
+	}
+	return &Ingestor{Source: src, Writer: w, cfg: cfg, window: map[string]*lagWindow{}}
+}
+
+// Run memproses event sampai ctx selesai atau source habis (io.EOF), lalu flush terakhir.
+func (in *Ingestor) Run(ctx context.Context, logger *slog.Logger) error {
+	msgs := make(chan Message)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(msgs)
+		for {
+			msg, err := in.Source.Next(ctx)
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	t := time.NewTicker(in.cfg.FlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				in.flush(context.WithoutCancel(ctx), logger)
+				var err error
+				select {
+				case err = <-errc:
+				default:
+				}
+				if err == nil || errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+			if err := in.Handle(ctx, msg); err != nil && !errors.Is(err, ErrTombstone) {
+				logger.Warn("cdc: skip message", "topic", msg.Topic, "error", err)
+			}
+		case <-t.C:
+			in.flush(ctx, logger)
+		case <-ctx.Done():
+			in.flush(context.WithoutCancel(ctx), logger)
+			return nil
+		}
+	}
+}
+
+// Handle mem-parse satu message dan memasukkannya ke window agregasi.
+func (in *Ingestor) Handle(ctx context.Context, msg Message) error {
+	ev, err := Parse(msg.Value, msg.Timestamp)
+	if err != nil {
+		return err
+	}
+	if ev.Table == "" {
+		return errors.New("debezium envelope without source.table")
+	}
+
+	in.mu.Lock()
+	w := in.window[ev.Table]
+	if w == nil {
+		w = &lagWindow{sourceName: sourceName(ev.Source)}
+		in.window[ev.Table] = w
+	}
+	if ev.IsSnapshot() {
+		w.snapshots++
+	} else {
+		lat := ev.Latency().Milliseconds()
+		if lat < 0 {
+			lat = 0 // clock skew antar host
+		}
+		w.lat = append(w.lat, lat)
+	}
+	if ev.SourceTime.After(w.lastSource) {
+		w.lastSource = ev.SourceTime
+	}
+	if ev.SinkTime.After(w.lastSink) {
+		w.lastSink = ev.SinkTime
+	}
+	in.mu.Unlock()
+
+	if in.OnEvent != nil {
+		return in.OnEvent(ctx, ev)
+	}
+	return nil
+}
+
+// Snapshot mengambil agregat window saat ini dan mengosongkannya.
+func (in *Ingestor) Snapshot() []TableLag {
+	in.mu.Lock()
+	win := in.window
+	in.window = map[string]*lagWindow{}
+	in.mu.Unlock()
+
+	out := make([]TableLag, 0, len(win))
+	for table, w := range win {
+		tl := TableLag{
+			Table:          table,
+			SourceName:     w.sourceName,
+			Events:         len(w.lat) + w.snapshots,
+			Snapshots:      w.snapshots,
+			LastSourceTime: w.lastSource,
+			LastSinkTime:   w.lastSink,
+		}
+		if len(w.lat) > 0 {
+			sort.Slice(w.lat, func(i, j int) bool { return w.lat[i] < w.lat[j] })
+			tl.P50Ms = percentile(w.lat, 50)
+			tl.P95Ms = percentile(w.lat, 95)
+			tl.MaxMs = w.lat[len(w.lat)-1]
+		}
+		out = append(out, tl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Table < out[j].Table })
+	return out
+}
+
+func (in *Ingestor) flush(ctx context.Context, logger *slog.Logger) {
+	lags := in.Snapshot()
+	if len(lags) == 0 {
+		return
+	}
+	wctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := in.Writer.WriteLag(wctx, lags, time.Now().UTC()); err != nil {
+		logger.Error("cdc: write lag aggregate failed", "error", err)
+	}
+}
+
+func sourceName(s SourceInfo) string {
+	name := "mysql"
+	if s.DB != "" {
+		name += "." + s.DB
+	}
+	return name + "." + s.Table
+}
+
+// percentile nearest-rank; xs harus sudah terurut naik.
+func percentile(xs []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(xs))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(xs) {
+		rank = len(xs)
+	}
+	return xs[rank-1]
+}
+
+// SQLAuditWriter menulis satu baris sync_audit per tabel per flush.
+// lag_seconds diisi p95 latency window (dibulatkan ke atas) supaya selaras dengan target SLA p95.
+type SQLAuditWriter struct {
+	DB         *sql.DB
+	Table      string // default sync_audit
+	ToolName   string // default debezium
+	TargetName string // prefix target, default "postgres"
+}
+
+func (w *SQLAuditWriter) WriteLag(ctx context.Context, lags []TableLag, flushedAt time.Time) error {
+	table := w.Table
+	if table == "" {
+		table = "sync_audit"
+	}
+	tool := w.ToolName
+	if tool == "" {
+		tool = "debezium"
+	}
+	target := w.TargetName
+	if target == "" {
+		target = "postgres"
+	}
+
+	q := fmt.Sprintf(`
+		INSERT INTO %s (
+			tool_name, source_name, target_name,
+			last_source_ts, last_target_ts, lag_seconds,
+			last_success_at, last_error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULL, $7)`, table)
+
+	for _, l := range lags {
+		var lag sql.NullInt64
+		if l.Events > l.Snapshots {
+			lag = sql.NullInt64{Int64: int64(math.Ceil(float64(l.P95Ms) / 1000)), Valid: true}
+		}
+		if _, err := w.DB.ExecContext(ctx, q,
+			tool, l.SourceName, target+"."+l.Table,
+			l.LastSourceTime, l.LastSinkTime, lag,
+			flushedAt,
+		); err != nil {
+			return fmt.Errorf("insert %s for %s: %w", table, l.Table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// internal/cdc/ingest_test.go
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIngestorAggregation(t *testing.T) {
+	base := time.UnixMilli(1_700_000_000_000).UTC()
+	msg := func(table, op string, latencyMs int64, snapshot bool) Message {
+		return Message{
+			Value: []byte(fmt.Sprintf(`{"op":%q,"after":{"id":1},"source":{"db":"mks","table":%q,"ts_ms":%d,"snapshot":%t}}`,
+				op, table, base.UnixMilli(), snapshot)),
+			Timestamp: base.Add(time.Duration(latencyMs) * time.Millisecond),
+		}
+	}
+
+	in := NewIngestor(nil, nil, IngestorConfig{})
+	msgs := []Message{
+		msg("customers", OpCreate, 100, false),
+		msg("customers", OpUpdate, 300, false),
+		msg("customers", OpUpdate, 200, false),
+		msg("customers", OpDelete, 2000, false),
+		msg("customers", OpRead, 99999, true), // snapshot: tidak dihitung ke latency
+		msg("credit_applications", OpRead, 5000, true),
+		msg("vehicles", OpCreate, -50, false), // clock skew: dibulatkan ke 0
+	}
+	for _, m := range msgs {
+		if err := in.Handle(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := in.Snapshot()
+	want := []TableLag{
+		{
+			Table: "credit_applications", SourceName: "mysql.mks.credit_applications", Events: 1, Snapshots: 1,
+			LastSourceTime: base, LastSinkTime: base.Add(5 * time.Second),
+		},
+		{
+			Table: "customers", SourceName: "mysql.mks.customers", Events: 5, Snapshots: 1,
+			P50Ms: 200, P95Ms: 2000, MaxMs: 2000,
+			LastSourceTime: base, LastSinkTime: base.Add(99999 * time.Millisecond),
+		},
+		{
+			Table: "vehicles", SourceName: "mysql.mks.vehicles", Events: 1,
+			LastSourceTime: base, LastSinkTime: base.Add(-50 * time.Millisecond),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if again := in.Snapshot(); len(again) != 0 {
+		t.Errorf("window not reset after snapshot: %+v", again)
+	}
+}
+
+func TestIngestorRejectsEventWithoutTable(t *testing.T) {
+	in := NewIngestor(nil, nil, IngestorConfig{})
+	err := in.Handle(context.Background(), Message{Value: []byte(`{"op":"c","source":{"ts_ms":1700000000000}}`)})
+	if err == nil {
+		t.Fatal("expected error for envelope without source.table")
+	}
+	if got := in.Snapshot(); len(got) != 0 {
+		t.Errorf("snapshot = %+v, want empty", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		xs   []int64
+		p    float64
+		want int64
+	}{
+		{xs: []int64{7}, p: 50, want: 7},
+		{xs: []int64{7}, p: 95, want: 7},
+		{xs: []int64{1, 2}, p: 50, want: 1},
+		{xs: []int64{1, 2, 3, 4}, p: 50, want: 2},
+		{xs: []int64{1, 2, 3, 4}, p: 95, want: 4},
+		{xs: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 95, want: 10},
+		{xs: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, p: 0, want: 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.xs, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %v) = %d, want %d", tt.xs, tt.p, got, tt.want)
+		}
+	}
+}
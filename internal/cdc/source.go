@@ -0,0 +1,186 @@
+// internal/cdc/source.go
+package cdc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Message adalah satu record mentah dari sumber event CDC.
+type Message struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Timestamp time.Time // waktu record ditulis ke Kafka; zero (file/stdin) = pakai ts_ms envelope, lihat Parse
+}
+
+// Source adalah sumber event Debezium yang bisa diganti-ganti (file replay, stdin, Kafka).
+// Next mengembalikan io.EOF kalau sumber sudah habis.
+type Source interface {
+	Next(ctx context.Context) (Message, error)
+	Close() error
+}
+
+// ReaderSource membaca satu JSON Debezium per baris (mis. hasil kafka-console-consumer
+// yang disimpan ke file, atau dipipe lewat stdin).
+type ReaderSource struct {
+	sc     *bufio.Scanner
+	closer io.Closer
+}
+
+func NewReaderSource(r io.Reader) *ReaderSource {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // event dengan banyak kolom bisa besar
+	s := &ReaderSource{sc: sc}
+	if c, ok := r.(io.Closer); ok {
+		s.closer = c
+	}
+	return s
+}
+
+func (s *ReaderSource) Next(ctx context.Context) (Message, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Message{}, err
+		}
+		if !s.sc.Scan() {
+			if err := s.sc.Err(); err != nil {
+				return Message{}, err
+			}
+			return Message{}, io.EOF
+		}
+		line := s.sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		v := make([]byte, len(line))
+		copy(v, line)
+		return Message{Value: v}, nil
+	}
+}
+
+func (s *ReaderSource) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// KafkaConsumer adalah kontrak minimal consumer Kafka yang dibutuhkan ingestor.
+// Implementasi asli (mis. franz-go / segmentio) cukup membungkus client-nya.
+type KafkaConsumer interface {
+	Poll(ctx context.Context) (Message, error)
+	Commit(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// KafkaSource mengadaptasi KafkaConsumer menjadi Source; offset di-commit saat
+// message berikutnya diminta (at-least-once).
+type KafkaSource struct {
+	Consumer KafkaConsumer
+
+	pending *Message
+}
+
+func NewKafkaSource(c KafkaConsumer) *KafkaSource {
+	return &KafkaSource{Consumer: c}
+}
+
+func (s *KafkaSource) Next(ctx context.Context) (Message, error) {
+	if s.pending != nil {
+		if err := s.Consumer.Commit(ctx, *s.pending); err != nil {
+			return Message{}, err
+		}
+		s.pending = nil
+	}
+	msg, err := s.Consumer.Poll(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+	s.pending = &msg
+	return msg, nil
+}
+
+func (s *KafkaSource) Close() error {
+	return s.Consumer.Close()
+}
+
+// FakeConsumer adalah KafkaConsumer in-memory untuk dev/test tanpa broker.
+// Poll menunggu sampai ada message (Publish) atau consumer ditutup.
+type FakeConsumer struct {
+	mu        sync.Mutex
+	queue     []Message
+	committed []Message
+	notify    chan struct{}
+	closed    bool
+}
+
+func NewFakeConsumer(msgs ...Message) *FakeConsumer {
+	return &FakeConsumer{queue: msgs, notify: make(chan struct{}, 1)}
+}
+
+// Publish menambahkan message ke antrean.
+func (f *FakeConsumer) Publish(msgs ...Message) {
+	f.mu.Lock()
+	f.queue = append(f.queue, msgs...)
+	f.mu.Unlock()
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Committed mengembalikan message yang sudah di-commit (urut).
+func (f *FakeConsumer) Committed() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.committed...)
+}
+
+func (f *FakeConsumer) Poll(ctx context.Context) (Message, error) {
+	for {
+		f.mu.Lock()
+		if len(f.queue) > 0 {
+			msg := f.queue[0]
+			f.queue = f.queue[1:]
+			f.mu.Unlock()
+			return msg, nil
+		}
+		closed := f.closed
+		f.mu.Unlock()
+		if closed {
+			return Message{}, io.EOF
+		}
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-f.notify:
+		}
+	}
+}
+
+func (f *FakeConsumer) Commit(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return errors.New("consumer closed")
+	}
+	f.committed = append(f.committed, msg)
+	return nil
+}
+
+func (f *FakeConsumer) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
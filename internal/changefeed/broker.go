@@ -0,0 +1,98 @@
+// internal/changefeed/broker.go
+package changefeed
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Event adalah satu perubahan baris yang terkait dengan seorang customer.
+type Event struct {
+	ID         uint64    `json:"id"`
+	Table      string    `json:"table"`
+	Op         string    `json:"op"` // INSERT | UPDATE | DELETE
+	CustomerID string    `json:"customer_id"`
+	RecordID   string    `json:"record_id"`
+	At         time.Time `json:"at"`
+}
+
+var ErrTooManySubscribers = errors.New("too many change feed subscribers")
+
+// Broker mem-fan-out event hanya ke subscriber customer yang bersangkutan.
+type Broker struct {
+	maxSubscribers int
+
+	mu     sync.Mutex
+	nextID uint64
+	subs   map[string]map[chan Event]struct{}
+	total  int
+}
+
+func NewBroker(maxSubscribers int) *Broker {
+	if maxSubscribers <= 0 {
+		maxSubscribers = 500
+	}
+	return &Broker{maxSubscribers: maxSubscribers, subs: map[string]map[chan Event]struct{}{}}
+}
+
+// Subscribe mendaftarkan subscriber untuk satu customer. Panggil fungsi cancel saat selesai.
+func (b *Broker) Subscribe(customerID string) (<-chan Event, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.total >= b.maxSubscribers {
+		return nil, nil, ErrTooManySubscribers
+	}
+
+	ch := make(chan Event, 16)
+	set := b.subs[customerID]
+	if set == nil {
+		set = map[chan Event]struct{}{}
+		b.subs[customerID] = set
+	}
+	set[ch] = struct{}{}
+	b.total++
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(customerID, ch)
+	}
+	return ch, cancel, nil
+}
+
+// remove harus dipanggil dengan b.mu terkunci.
+func (b *Broker) remove(customerID string, ch chan Event) {
+	set := b.subs[customerID]
+	if _, ok := set[ch]; !ok {
+		return
+	}
+	delete(set, ch)
+	close(ch)
+	b.total--
+	if len(set) == 0 {
+		delete(b.subs, customerID)
+	}
+}
+
+// Publish mengirim event ke subscriber customer terkait. Subscriber yang terlalu lambat diputus.
+func (b *Broker) Publish(ev Event) {
+	if ev.CustomerID == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	ev.ID = b.nextID
+
+	for ch := range b.subs[ev.CustomerID] {
+		select {
+		case ch <- ev:
+		default:
+			b.remove(ev.CustomerID, ch)
+		}
+	}
+}
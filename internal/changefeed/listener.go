@@ -0,0 +1,73 @@
+// internal/changefeed/listener.go
+package changefeed
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listener memakai koneksi pgx khusus (bukan dari pool database/sql) untuk LISTEN,
+// karena koneksi LISTEN harus tetap terbuka dan tidak boleh dipakai query lain.
+type Listener struct {
+	DSN     string
+	Channel string
+	Broker  *Broker
+}
+
+// Run mendengarkan NOTIFY sampai ctx selesai, reconnect dengan backoff kalau koneksi putus.
+func (l *Listener) Run(ctx context.Context, logger *slog.Logger) error {
+	if err := validChannel(l.Channel); err != nil {
+		return err
+	}
+
+	backoff := time.Second
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = time.Second
+		}
+		logger.Warn("changefeed: listener disconnected", "error", err, "retry_in", backoff.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// listen mengembalikan connected=true kalau LISTEN sempat berhasil (untuk reset backoff).
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.Connect(ctx, l.DSN)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.Channel}.Sanitize()); err != nil {
+		return false, err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		var ev Event
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			continue // payload bukan dari trigger kita
+		}
+		l.Broker.Publish(ev)
+	}
+}
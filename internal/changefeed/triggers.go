@@ -0,0 +1,88 @@
+// internal/changefeed/triggers.go
+package changefeed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// WatchedTable adalah tabel ODS yang perubahannya dikirim lewat NOTIFY.
+type WatchedTable struct {
+	Table      string
+	PrimaryKey string
+}
+
+var WatchedTables = []WatchedTable{
+	{Table: "customers", PrimaryKey: "customer_id"},
+	{Table: "credit_applications", PrimaryKey: "application_id"},
+	{Table: "vehicle_ownership", PrimaryKey: "ownership_id"},
+}
+
+const notifyFunction = "mks_notify_customer_change"
+
+var channelRe = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+func validChannel(ch string) error {
+	if !channelRe.MatchString(ch) {
+		return fmt.Errorf("invalid notify channel %q (lowercase identifier expected)", ch)
+	}
+	return nil
+}
+
+// InstallTriggers membuat (atau mengganti) function + trigger AFTER INSERT/UPDATE/DELETE
+// yang memanggil pg_notify(channel, json) untuk tiap tabel di WatchedTables.
+// Kalau DBA lebih suka memasang trigger sendiri, cukup pakai SQL yang sama dan matikan opsi ini.
+func InstallTriggers(ctx context.Context, db *sql.DB, channel string) error {
+	if err := validChannel(channel); err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Payload dijaga kecil (batas NOTIFY 8000 byte): hanya id, bukan isi baris.
+	fn := fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+		DECLARE
+			rec jsonb;
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				rec := to_jsonb(OLD);
+			ELSE
+				rec := to_jsonb(NEW);
+			END IF;
+			PERFORM pg_notify('%s', json_build_object(
+				'table', TG_TABLE_NAME,
+				'op', TG_OP,
+				'customer_id', rec ->> 'customer_id',
+				'record_id', rec ->> TG_ARGV[0],
+				'at', now()
+			)::text);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`, notifyFunction, channel)
+	if _, err := tx.ExecContext(ctx, fn); err != nil {
+		return fmt.Errorf("create notify function: %w", err)
+	}
+
+	for _, t := range WatchedTables {
+		trigger := notifyFunction + "_" + t.Table
+		stmts := []string{
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, trigger, t.Table),
+			fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s
+				FOR EACH ROW EXECUTE FUNCTION %s('%s')`, trigger, t.Table, notifyFunction, t.PrimaryKey),
+		}
+		for _, q := range stmts {
+			if _, err := tx.ExecContext(ctx, q); err != nil {
+				return fmt.Errorf("install trigger on %s: %w", t.Table, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
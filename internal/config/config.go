@@ -56,6 +56,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -67,6 +70,142 @@ type Config struct {
 	DBPass    string
 	DBName    string
 	DBSSLMode string // postgres only
+
+	// Anomaly detection untuk row count customers / credit_applications
+	KPIAnomalyWindow         int           // jumlah sample yang disimpan (rolling)
+	KPIAnomalyMinSamples     int           // minimal sample sebelum z-score dipakai
+	KPIAnomalyZScore         float64       // |z| di atas nilai ini dianggap anomali
+	KPIAnomalyPctChange      float64       // perubahan (%) vs sample terakhir yang dianggap anomali
+	KPIAnomalySampleInterval time.Duration // jarak minimal antar sample (supaya polling UI tidak membanjiri history)
+
+	// Freshness per tabel ODS (0 = rule dimatikan)
+	TableFreshnessWarn  time.Duration
+	TableFreshnessError time.Duration
+
+	// SLA policy default untuk /sync/health (0 = rule dimatikan); override per tabel via file JSON
+	SLALagWarn         time.Duration
+	SLALagError        time.Duration
+	SLAMaxSinceSuccess time.Duration
+	SLAMaxAuditAge     time.Duration
+	SLAPolicyFile      string
+
+	// Metadata freshness di response: off | headers | flag | reject
+	FreshnessMode        string
+	FreshnessCacheTTL    time.Duration
+	FreshnessMetaDefault bool
+
+	// Autentikasi JWT/OIDC (aktif kalau AuthIssuer diisi)
+	AuthIssuer      string
+	AuthAudience    string
+	AuthJWKS        string // path file / file:/path / URL; kosong = OIDC discovery dari issuer
+	AuthRolesClaim  string
+	AuthLeeway      time.Duration
+	AuthJWKSRefresh time.Duration
+
+	// RBAC: aktif bersama auth; kosong = policy bawaan (admin/analyst/branch_*/ops)
+	RBACPolicyFile string
+
+	// Masking PII (NIK, telepon, email, alamat, kontak darurat) per role
+	PIIMasking    bool
+	PIIPolicyFile string
+
+	// Audit akses data customer (tabel access_audit)
+	AuditEnabled  bool
+	AuditFailOpen bool
+	// false: tabel dibuat DBA dengan owner role terpisah; aplikasi hanya INSERT/SELECT
+	AuditManageSchema bool
+
+	// API key service-to-service (butuh auth aktif)
+	APIKeysEnabled   bool
+	APIKeyDefaultTTL time.Duration
+
+	// Rate limit per klien (API key / user / IP) + concurrency per route
+	RateLimitEnabled       bool
+	RateLimitRPS           float64
+	RateLimitBurst         int
+	RateLimitMaxConcurrent int
+	RateLimitFile          string
+
+	// Proxy (IP/CIDR) yang boleh menentukan IP klien lewat X-Forwarded-For / X-Real-IP
+	TrustedProxies []string
+
+	// Logging (slog): level debug|info|warn|error, format json|text; AccessLog = satu baris per request
+	LogLevel  string
+	LogFormat string
+	AccessLog bool
+
+	// Prometheus GET /metrics; MetricsPublic = tanpa auth (scrape dari jaringan internal)
+	MetricsEnabled bool
+	MetricsPublic  bool
+
+	// Tracing: exporter none | stdout | otlp (OTLP/HTTP ke <endpoint>/v1/traces)
+	TracesExporter     string
+	OTLPEndpoint       string
+	OTLPHeaders        map[string]string
+	TracingServiceName string
+	// Sampler OTEL_TRACES_SAMPLER; arg = rasio untuk *traceidratio
+	TracesSampler    string
+	TracesSamplerArg float64
+
+	// CORS (aktif kalau CORSAllowedOrigins diisi)
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// Kafka Connect REST API (kosong = probe connector dimatikan)
+	KafkaConnectURL        string
+	KafkaConnectConnectors []string
+	KafkaConnectTimeout    time.Duration
+
+	// MySQL source (opsional, untuk rekonsiliasi source vs ODS)
+	SourceDBHost string
+	SourceDBPort string
+	SourceDBUser string
+	SourceDBPass string
+	SourceDBName string
+
+	ReconcileInterval  time.Duration // 0 = hanya manual via POST /sync/reconciliation/run
+	ReconcileChunkSize int
+	ReconcileMaxDiffs  int
+
+	// Heartbeat CDC end-to-end (butuh SOURCE_DB_*; interval 0 = dimatikan)
+	HeartbeatInterval     time.Duration
+	HeartbeatTimeout      time.Duration
+	HeartbeatPollInterval time.Duration
+	HeartbeatSourceTable  string
+	HeartbeatTargetTable  string
+	HeartbeatAuditTable   string
+
+	// Ingest event Debezium untuk menghitung lag: "" (mati) | "stdin" | "file:/path/events.jsonl"
+	CDCEventsSource  string
+	CDCFlushInterval time.Duration
+
+	// SSE /stream/dashboard
+	StreamMaxSubscribers    int
+	StreamSyncPollInterval  time.Duration
+	StreamKPIInterval       time.Duration
+	StreamHeartbeatInterval time.Duration
+
+	// Change feed customer via LISTEN/NOTIFY
+	ChangeFeedEnabled         bool
+	ChangeFeedChannel         string
+	ChangeFeedInstallTriggers bool // false = trigger diasumsikan sudah dipasang DBA
+	ChangeFeedMaxSubscribers  int
+
+	// Histori perubahan customer: "" (nonaktif) | trigger | debezium
+	HistoryMode string
+
+	// Webhook notifikasi transisi status sync
+	SyncWebhookURLs        []string
+	SyncWebhookSecret      string
+	SyncNotifyInterval     time.Duration
+	SyncWebhookMaxAttempts int
+	SyncWebhookBackoff     time.Duration
+	SyncWebhookTimeout     time.Duration
+	SyncNotifyDedupWindow  time.Duration
 }
 
 func Load() (Config, error) {
@@ -79,6 +218,159 @@ func Load() (Config, error) {
 		DBPass:    getenv("DB_PASSWORD", ""),
 		DBName:    getenv("DB_NAME", ""),
 		DBSSLMode: getenv("DB_SSLMODE", "disable"),
+
+		KPIAnomalyWindow:         getenvInt("KPI_ANOMALY_WINDOW", 30),
+		KPIAnomalyMinSamples:     getenvInt("KPI_ANOMALY_MIN_SAMPLES", 5),
+		KPIAnomalyZScore:         getenvFloat("KPI_ANOMALY_ZSCORE", 3),
+		KPIAnomalyPctChange:      getenvFloat("KPI_ANOMALY_PCT_CHANGE", 20),
+		KPIAnomalySampleInterval: getenvDuration("KPI_ANOMALY_SAMPLE_INTERVAL", time.Minute),
+
+		TableFreshnessWarn:  getenvDuration("TABLE_FRESHNESS_WARN", time.Hour),
+		TableFreshnessError: getenvDuration("TABLE_FRESHNESS_ERROR", 24*time.Hour),
+
+		SLALagWarn:         getenvDuration("SLA_LAG_WARN", 10*time.Second),
+		SLALagError:        getenvDuration("SLA_LAG_ERROR", 0),
+		SLAMaxSinceSuccess: getenvDuration("SLA_MAX_SINCE_SUCCESS", 0),
+		SLAMaxAuditAge:     getenvDuration("SLA_MAX_AUDIT_AGE", 0),
+		SLAPolicyFile:      getenv("SLA_POLICY_FILE", ""),
+
+		FreshnessMode:        strings.ToLower(getenv("FRESHNESS_MODE", "headers")),
+		FreshnessCacheTTL:    getenvDuration("FRESHNESS_CACHE_TTL", 5*time.Second),
+		FreshnessMetaDefault: getenvBool("FRESHNESS_META_DEFAULT", false),
+
+		AuthIssuer:      getenv("AUTH_ISSUER", ""),
+		AuthAudience:    getenv("AUTH_AUDIENCE", ""),
+		AuthJWKS:        getenv("AUTH_JWKS", ""),
+		AuthRolesClaim:  getenv("AUTH_ROLES_CLAIM", "roles"),
+		AuthLeeway:      getenvDuration("AUTH_LEEWAY", 30*time.Second),
+		AuthJWKSRefresh: getenvDuration("AUTH_JWKS_REFRESH", time.Hour),
+
+		RBACPolicyFile: getenv("RBAC_POLICY_FILE", ""),
+
+		PIIMasking:    getenvBool("PII_MASKING", true),
+		PIIPolicyFile: getenv("PII_POLICY_FILE", ""),
+
+		AuditEnabled:  getenvBool("AUDIT_ENABLED", false),
+		AuditFailOpen: getenvBool("AUDIT_FAIL_OPEN", false),
+
+		AuditManageSchema: getenvBool("AUDIT_MANAGE_SCHEMA", true),
+
+		APIKeysEnabled:   getenvBool("API_KEYS_ENABLED", false),
+		APIKeyDefaultTTL: getenvDuration("API_KEY_DEFAULT_TTL", 90*24*time.Hour),
+
+		RateLimitEnabled:       getenvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitRPS:           getenvFloat("RATE_LIMIT_RPS", 20),
+		RateLimitBurst:         getenvInt("RATE_LIMIT_BURST", 40),
+		RateLimitMaxConcurrent: getenvInt("RATE_LIMIT_MAX_CONCURRENT", 0),
+		RateLimitFile:          getenv("RATE_LIMIT_FILE", ""),
+
+		TrustedProxies: getenvList("TRUSTED_PROXIES"),
+
+		LogLevel:  strings.ToLower(getenv("LOG_LEVEL", "info")),
+		LogFormat: strings.ToLower(getenv("LOG_FORMAT", "json")),
+		AccessLog: getenvBool("ACCESS_LOG", true),
+
+		MetricsEnabled: getenvBool("METRICS_ENABLED", true),
+		MetricsPublic:  getenvBool("METRICS_PUBLIC", false),
+
+		TracesExporter:     strings.ToLower(getenv("OTEL_TRACES_EXPORTER", "none")),
+		OTLPEndpoint:       getenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		OTLPHeaders:        getenvMap("OTEL_EXPORTER_OTLP_HEADERS"),
+		TracingServiceName: getenv("OTEL_SERVICE_NAME", "mks-backend"),
+		TracesSampler:      strings.ToLower(getenv("OTEL_TRACES_SAMPLER", "parentbased_traceidratio")),
+		TracesSamplerArg:   getenvFloat("OTEL_TRACES_SAMPLER_ARG", 0.1),
+
+		CORSAllowedOrigins: getenvList("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedMethods: getenvListDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		CORSAllowedHeaders: getenvListDefault("CORS_ALLOWED_HEADERS",
+			[]string{"Authorization", "Content-Type", "X-API-Key", "X-Request-Id", "Last-Event-ID", "traceparent", "tracestate"}),
+		CORSExposedHeaders: getenvListDefault("CORS_EXPOSED_HEADERS", []string{
+			"X-Data-As-Of", "X-Sync-Lag-Seconds", "X-Data-Stale", "X-Data-Freshness",
+			"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+			"WWW-Authenticate", "X-Request-ID",
+		}),
+		CORSAllowCredentials: getenvBool("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           getenvDuration("CORS_MAX_AGE", 10*time.Minute),
+
+		KafkaConnectURL:        getenv("KAFKA_CONNECT_URL", ""),
+		KafkaConnectConnectors: getenvList("KAFKA_CONNECT_CONNECTORS"),
+		KafkaConnectTimeout:    getenvDuration("KAFKA_CONNECT_TIMEOUT", 2*time.Second),
+
+		SourceDBHost: getenv("SOURCE_DB_HOST", ""),
+		SourceDBPort: getenv("SOURCE_DB_PORT", "3306"),
+		SourceDBUser: getenv("SOURCE_DB_USER", ""),
+		SourceDBPass: getenv("SOURCE_DB_PASSWORD", ""),
+		SourceDBName: getenv("SOURCE_DB_NAME", ""),
+
+		ReconcileInterval:  getenvDuration("RECONCILE_INTERVAL", 0),
+		ReconcileChunkSize: getenvInt("RECONCILE_CHUNK_SIZE", 1000),
+		ReconcileMaxDiffs:  getenvInt("RECONCILE_MAX_DIFFS", 100),
+
+		HeartbeatInterval:     getenvDuration("HEARTBEAT_INTERVAL", 0),
+		HeartbeatTimeout:      getenvDuration("HEARTBEAT_TIMEOUT", time.Minute),
+		HeartbeatPollInterval: getenvDuration("HEARTBEAT_POLL_INTERVAL", 500*time.Millisecond),
+		HeartbeatSourceTable:  getenv("HEARTBEAT_SOURCE_TABLE", "cdc_heartbeat"),
+		HeartbeatTargetTable:  getenv("HEARTBEAT_TARGET_TABLE", "cdc_heartbeat"),
+		HeartbeatAuditTable:   getenv("HEARTBEAT_AUDIT_TABLE", "sync_audit"),
+
+		CDCEventsSource:  getenv("CDC_EVENTS_SOURCE", ""),
+		CDCFlushInterval: getenvDuration("CDC_FLUSH_INTERVAL", 10*time.Second),
+
+		StreamMaxSubscribers:    getenvInt("STREAM_MAX_SUBSCRIBERS", 100),
+		StreamSyncPollInterval:  getenvDuration("STREAM_SYNC_POLL_INTERVAL", 2*time.Second),
+		StreamKPIInterval:       getenvDuration("STREAM_KPI_INTERVAL", 30*time.Second),
+		StreamHeartbeatInterval: getenvDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+
+		ChangeFeedEnabled:         getenvBool("CHANGEFEED_ENABLED", false),
+		ChangeFeedChannel:         getenv("CHANGEFEED_CHANNEL", "customer_changes"),
+		ChangeFeedInstallTriggers: getenvBool("CHANGEFEED_INSTALL_TRIGGERS", false),
+		ChangeFeedMaxSubscribers:  getenvInt("CHANGEFEED_MAX_SUBSCRIBERS", 500),
+
+		HistoryMode: strings.ToLower(getenv("HISTORY_MODE", "")),
+
+		SyncWebhookURLs:        getenvList("SYNC_WEBHOOK_URLS"),
+		SyncWebhookSecret:      getenv("SYNC_WEBHOOK_SECRET", ""),
+		SyncNotifyInterval:     getenvDuration("SYNC_NOTIFY_INTERVAL", 30*time.Second),
+		SyncWebhookMaxAttempts: getenvInt("SYNC_WEBHOOK_MAX_ATTEMPTS", 5),
+		SyncWebhookBackoff:     getenvDuration("SYNC_WEBHOOK_BACKOFF", 2*time.Second),
+		SyncWebhookTimeout:     getenvDuration("SYNC_WEBHOOK_TIMEOUT", 5*time.Second),
+		SyncNotifyDedupWindow:  getenvDuration("SYNC_NOTIFY_DEDUP_WINDOW", 15*time.Minute),
+	}
+
+	switch c.HistoryMode {
+	case "", "trigger", "debezium":
+	default:
+		return c, fmt.Errorf("invalid HISTORY_MODE %q (want trigger or debezium)", c.HistoryMode)
+	}
+	switch c.FreshnessMode {
+	case "off", "headers", "flag", "reject":
+	default:
+		return c, fmt.Errorf("invalid FRESHNESS_MODE %q (want off, headers, flag or reject)", c.FreshnessMode)
+	}
+	switch c.TracesExporter {
+	case "none", "stdout", "otlp":
+	case "console": // nama exporter stdout di SDK OpenTelemetry
+		c.TracesExporter = "stdout"
+	default:
+		return c, fmt.Errorf("invalid OTEL_TRACES_EXPORTER %q (want none, stdout or otlp)", c.TracesExporter)
+	}
+	switch c.TracesSampler {
+	case "always_on", "always_off", "parentbased_always_on", "parentbased_always_off":
+	case "traceidratio", "parentbased_traceidratio":
+		if c.TracesSamplerArg < 0 || c.TracesSamplerArg > 1 {
+			return c, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %v (want a ratio between 0 and 1)", c.TracesSamplerArg)
+		}
+	default:
+		return c, fmt.Errorf("invalid OTEL_TRACES_SAMPLER %q (want always_on, always_off, traceidratio or parentbased_*)", c.TracesSampler)
+	}
+	if c.AuthIssuer != "" && c.AuthAudience == "" {
+		return c, fmt.Errorf("AUTH_AUDIENCE is required when AUTH_ISSUER is set")
+	}
+	if c.APIKeysEnabled && c.AuthIssuer == "" {
+		return c, fmt.Errorf("API_KEYS_ENABLED requires AUTH_ISSUER")
+	}
+	if c.HistoryMode == "debezium" && c.CDCEventsSource == "" {
+		return c, fmt.Errorf("HISTORY_MODE=debezium requires CDC_EVENTS_SOURCE")
 	}
 
 	if c.DBUser == "" || c.DBName == "" {
@@ -95,6 +387,19 @@ func (c Config) PostgresDSN() string {
 	)
 }
 
+// HasSourceDB: MySQL source dianggap dikonfigurasi kalau host, user & nama DB terisi.
+func (c Config) HasSourceDB() bool {
+	return c.SourceDBHost != "" && c.SourceDBUser != "" && c.SourceDBName != ""
+}
+
+func (c Config) MySQLDSN() string {
+	// parseTime penting untuk DATETIME/DATE
+	// loc=UTC aman untuk POC; nanti bisa disesuaikan Asia/Jakarta
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&charset=utf8mb4&loc=UTC",
+		c.SourceDBUser, c.SourceDBPass, c.SourceDBHost, c.SourceDBPort, c.SourceDBName,
+	)
+}
+
 func getenv(key, def string) string {
 	v := os.Getenv(key)
 	if v == "" {
@@ -102,3 +407,84 @@ func getenv(key, def string) string {
 	}
 	return v
 }
+
+func getenvInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return i
+}
+
+func getenvFloat(key string, def float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+func getenvBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// getenvDuration menerima format time.ParseDuration ("30s", "5m") atau angka polos (detik).
+func getenvDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return d
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return time.Duration(n) * time.Second
+	}
+	return def
+}
+
+// getenvList membaca daftar dipisah koma, mengabaikan item kosong.
+func getenvList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// getenvMap membaca "k1=v1,k2=v2" (format OTEL_EXPORTER_OTLP_HEADERS).
+func getenvMap(key string) map[string]string {
+	out := map[string]string{}
+	for _, kv := range getenvList(key) {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.TrimSpace(k) != "" {
+			out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+// getenvListDefault: seperti getenvList, tapi def dipakai kalau env kosong.
+func getenvListDefault(key string, def []string) []string {
+	if out := getenvList(key); len(out) > 0 {
+		return out
+	}
+	return def
+}
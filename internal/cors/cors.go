@@ -0,0 +1,185 @@
+// internal/cors/cors.go
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config mengatur CORS. Origin boleh exact ("https://app.example.com"), wildcard subdomain
+// ("https://*.example.com") atau "*" (semua origin, tidak boleh bersama AllowCredentials).
+type Config struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string // "*" = terima semua header yang diminta preflight
+	ExposedHeaders   []string // header response yang boleh dibaca JavaScript
+	AllowCredentials bool
+	MaxAge           time.Duration // cache preflight di browser
+}
+
+// CORS menjawab preflight dan menambahkan header CORS ke response. Harus dipasang sebelum
+// auth & routing: preflight tidak membawa token dan tidak punya route OPTIONS.
+type CORS struct {
+	cfg       Config
+	exact     map[string]bool
+	wildcards []wildcard
+	any       bool
+	methods   map[string]bool
+	headers   map[string]bool
+	anyHeader bool
+}
+
+type wildcard struct{ prefix, suffix string }
+
+func New(cfg Config) (*CORS, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, errors.New("cors: no allowed origins")
+	}
+	c := &CORS{cfg: cfg, exact: map[string]bool{}, methods: map[string]bool{}, headers: map[string]bool{}}
+
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(strings.TrimRight(strings.TrimSpace(o), "/"))
+		switch n := strings.Count(o, "*"); {
+		case o == "*":
+			c.any = true
+		case n == 0:
+			c.exact[o] = true
+		case n == 1 && strings.Contains(o, "://*."):
+			prefix, suffix, _ := strings.Cut(o, "*")
+			c.wildcards = append(c.wildcards, wildcard{prefix: prefix, suffix: suffix})
+		default:
+			return nil, fmt.Errorf("cors: invalid origin pattern %q (use scheme://*.domain)", o)
+		}
+	}
+	if c.any && cfg.AllowCredentials {
+		return nil, errors.New(`cors: origin "*" cannot be combined with credentials; list the origins explicitly`)
+	}
+	for _, m := range cfg.AllowedMethods {
+		c.methods[strings.ToUpper(strings.TrimSpace(m))] = true
+	}
+	for _, h := range cfg.AllowedHeaders {
+		h = strings.TrimSpace(h)
+		if h == "*" {
+			c.anyHeader = true
+		}
+		c.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	return c, nil
+}
+
+// Middleware: CORS nil = CORS dimatikan (frontend harus lewat proxy same-origin).
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	if c == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		if c.allowedOrigin(origin) {
+			c.setOrigin(w, origin)
+			if len(c.cfg.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.cfg.ExposedHeaders, ", "))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflight dijawab langsung (tidak diteruskan ke handler). Penolakan = 403 tanpa header
+// Allow-*, sehingga browser memblokir request sebenarnya.
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	requested := requestedHeaders(r)
+	if !c.allowedOrigin(origin) || !c.methods[method] || !c.allowedHeaders(requested) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.setOrigin(w, origin)
+	methods := make([]string, 0, len(c.methods))
+	for _, m := range c.cfg.AllowedMethods {
+		methods = append(methods, strings.ToUpper(strings.TrimSpace(m)))
+	}
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(requested) > 0 {
+		// echo header yang diminta (semuanya sudah dicek), berlaku juga untuk AllowedHeaders "*"
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if c.cfg.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.cfg.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *CORS) setOrigin(w http.ResponseWriter, origin string) {
+	if c.any {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *CORS) allowedOrigin(origin string) bool {
+	if c.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.exact[origin] {
+		return true
+	}
+	for _, wc := range c.wildcards {
+		if len(origin) < len(wc.prefix)+len(wc.suffix) || !strings.HasPrefix(origin, wc.prefix) || !strings.HasSuffix(origin, wc.suffix) {
+			continue
+		}
+		sub := origin[len(wc.prefix) : len(origin)-len(wc.suffix)]
+		// minimal satu label; tidak boleh menyelipkan path/port/userinfo
+		if sub != "" && !strings.ContainsAny(sub, "/:@") && !strings.HasPrefix(sub, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) allowedHeaders(requested []string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, h := range requested {
+		if !c.headers[http.CanonicalHeaderKey(h)] {
+			return false
+		}
+	}
+	return true
+}
+
+func requestedHeaders(r *http.Request) []string {
+	out := []string{}
+	for _, v := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, h := range strings.Split(v, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				out = append(out, h)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,247 @@
+// internal/heartbeat/heartbeat.go
+package heartbeat
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Config mengatur heartbeat end-to-end: insert di MySQL source, tunggu muncul di ODS.
+type Config struct {
+	Interval     time.Duration // jarak antar heartbeat
+	Timeout      time.Duration // batas tunggu sampai baris muncul di ODS
+	PollInterval time.Duration // seberapa sering ODS dicek selama menunggu
+
+	SourceTable string // tabel heartbeat di MySQL (ikut di-capture Debezium)
+	TargetTable string // tabel hasil sink di Postgres
+	AuditTable  string // tempat hasil pengukuran ditulis (default sync_audit)
+
+	ToolName string // nilai tool_name di baris audit
+}
+
+// Result adalah hasil satu kali pengukuran.
+type Result struct {
+	ID        string        `json:"id"`
+	SentAt    time.Time     `json:"sent_at"`
+	SeenAt    *time.Time    `json:"seen_at"`
+	Latency   time.Duration `json:"-"` // saat gagal: lama menunggu (batas bawah lag)
+	LatencyMs *int64        `json:"latency_ms"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Worker mengukur latency CDC secara langsung (first-hand), tidak bergantung pada
+// proses eksternal yang mengisi sync_audit.
+type Worker struct {
+	Source *sql.DB // MySQL
+	Target *sql.DB // PostgreSQL (ODS)
+	cfg    Config
+
+	mu          sync.Mutex
+	last        *Result
+	lastSuccess *time.Time
+}
+
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+func New(source, target *sql.DB, cfg Config) (*Worker, error) {
+	if cfg.Interval <= 0 {
+		return nil, errors.New("heartbeat interval must be > 0")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Minute
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 500 * time.Millisecond
+	}
+	if cfg.SourceTable == "" {
+		cfg.SourceTable = "cdc_heartbeat"
+	}
+	if cfg.TargetTable == "" {
+		cfg.TargetTable = cfg.SourceTable
+	}
+	if cfg.AuditTable == "" {
+		cfg.AuditTable = "sync_audit"
+	}
+	if cfg.ToolName == "" {
+		cfg.ToolName = "heartbeat"
+	}
+
+	// nama tabel dirangkai ke SQL, jadi wajib identifier polos
+	for _, t := range []string{cfg.SourceTable, cfg.TargetTable, cfg.AuditTable} {
+		if !identRe.MatchString(t) {
+			return nil, fmt.Errorf("invalid heartbeat table name %q", t)
+		}
+	}
+
+	return &Worker{Source: source, Target: target, cfg: cfg}, nil
+}
+
+// Last mengembalikan hasil pengukuran terakhir (nil kalau belum ada).
+func (w *Worker) Last() *Result {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last
+}
+
+// EnsureSourceTable membuat tabel heartbeat di MySQL kalau belum ada.
+// Tabel di ODS dibuat oleh JDBC sink (auto.create) atau manual.
+func (w *Worker) EnsureSourceTable(ctx context.Context) error {
+	_, err := w.Source.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id        VARCHAR(32) NOT NULL PRIMARY KEY,
+			source_ts DATETIME(6) NOT NULL
+		)`, w.cfg.SourceTable))
+	return err
+}
+
+// Start menjalankan heartbeat secara periodik sampai ctx selesai.
+func (w *Worker) Start(ctx context.Context, logger *slog.Logger) {
+	if err := w.EnsureSourceTable(ctx); err != nil {
+		logger.Error("heartbeat: ensure source table failed", "table", w.cfg.SourceTable, "error", err)
+	}
+
+	t := time.NewTicker(w.cfg.Interval)
+	defer t.Stop()
+	for {
+		res, err := w.Beat(ctx)
+		if res.Error != "" && ctx.Err() == nil {
+			logger.Warn("heartbeat: round trip not completed", "id", res.ID, "detail", res.Error)
+		}
+		if err != nil {
+			logger.Error("heartbeat: write audit failed", "id", res.ID, "table", w.cfg.AuditTable, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Beat mengirim satu heartbeat, menunggu sampai terlihat di ODS, lalu mencatat hasilnya.
+// Error yang dikembalikan hanya untuk kegagalan menulis tabel audit; kegagalan
+// heartbeat sendiri ada di Result.Error.
+func (w *Worker) Beat(ctx context.Context) (Result, error) {
+	res := Result{ID: newID(), SentAt: time.Now().UTC()}
+
+	_, err := w.Source.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (id, source_ts) VALUES (?, ?)`, w.cfg.SourceTable),
+		res.ID, res.SentAt,
+	)
+	if err != nil {
+		res.Error = "insert into source failed: " + err.Error()
+		return res, w.record(ctx, res)
+	}
+
+	seenAt, err := w.waitForTarget(ctx, res.ID)
+	if err != nil {
+		res.Error = err.Error()
+		res.Latency = time.Since(res.SentAt) // batas bawah lag
+	} else {
+		res.SeenAt = &seenAt
+		res.Latency = seenAt.Sub(res.SentAt)
+		ms := res.Latency.Milliseconds()
+		res.LatencyMs = &ms
+	}
+
+	err = w.record(ctx, res)
+	w.cleanup(ctx)
+	return res, err
+}
+
+func (w *Worker) waitForTarget(ctx context.Context, id string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	defer cancel()
+
+	q := fmt.Sprintf(`SELECT 1 FROM %s WHERE id = $1`, w.cfg.TargetTable)
+	t := time.NewTicker(w.cfg.PollInterval)
+	defer t.Stop()
+
+	for {
+		var one int
+		err := w.Target.QueryRowContext(ctx, q, id).Scan(&one)
+		if err == nil {
+			return time.Now().UTC(), nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+			return time.Time{}, fmt.Errorf("query target failed: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return time.Time{}, fmt.Errorf("heartbeat not seen in %s after %s", w.cfg.TargetTable, w.cfg.Timeout)
+		case <-t.C:
+		}
+	}
+}
+
+// record menyimpan hasil ke memori dan menulis satu baris ke tabel audit,
+// sehingga /sync/health membaca lag hasil ukur langsung.
+func (w *Worker) record(ctx context.Context, res Result) error {
+	w.mu.Lock()
+	w.last = &res
+	if res.SeenAt != nil {
+		w.lastSuccess = res.SeenAt
+	}
+	lastSuccess := w.lastSuccess
+	w.mu.Unlock()
+
+	var (
+		lag     sql.NullInt64
+		seenAt  sql.NullTime
+		success sql.NullTime
+		lastErr sql.NullString
+	)
+	if res.SeenAt != nil {
+		seenAt = sql.NullTime{Time: *res.SeenAt, Valid: true}
+	}
+	if res.Latency > 0 {
+		lag = sql.NullInt64{Int64: int64(math.Round(res.Latency.Seconds())), Valid: true}
+	}
+	if lastSuccess != nil {
+		success = sql.NullTime{Time: *lastSuccess, Valid: true}
+	}
+	if res.Error != "" {
+		lastErr = sql.NullString{String: res.Error, Valid: true}
+	}
+
+	// ctx worker bisa sudah selesai (shutdown); tetap coba tulis hasil terakhir.
+	wctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	_, err := w.Target.ExecContext(wctx, fmt.Sprintf(`
+		INSERT INTO %s (
+			tool_name, source_name, target_name,
+			last_source_ts, last_target_ts, lag_seconds,
+			last_success_at, last_error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())`, w.cfg.AuditTable),
+		w.cfg.ToolName, "mysql."+w.cfg.SourceTable, "postgres."+w.cfg.TargetTable,
+		res.SentAt, seenAt, lag,
+		success, lastErr,
+	)
+	return err
+}
+
+// cleanup membuang heartbeat lama di source supaya tabel tetap kecil.
+func (w *Worker) cleanup(ctx context.Context) {
+	_, _ = w.Source.ExecContext(ctx,
+		fmt.Sprintf(`DELETE FROM %s WHERE source_ts < ?`, w.cfg.SourceTable),
+		time.Now().UTC().Add(-24*time.Hour),
+	)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+// internal/history/asof.go
+package history
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AsOfSource mengembalikan subquery yang merekonstruksi isi tabel pada waktu tertentu,
+// untuk dipakai sebagai pengganti nama tabel di FROM. Subquery diberi alias nama tabel
+// aslinya, jadi kolom di query luar tidak perlu diubah.
+//
+// asOfPH adalah placeholder waktu (mis. "$3"); state yang dihasilkan sudah mencakup
+// semua perubahan dengan changed_at <= as_of.
+//
+// Cara kerja: mulai dari state sekarang, lalu batalkan perubahan setelah as_of.
+// Nilai kolom pada as_of = old_value dari perubahan pertama setelah as_of yang menyentuh
+// kolom itu (DELETE menyentuh semua kolom); kolom yang tidak pernah berubah memakai nilai
+// sekarang. Record yang perubahan pertamanya setelah as_of adalah INSERT berarti belum ada.
+func AsOfSource(table, asOfPH string) (string, error) {
+	t, ok := trackedTable(table)
+	if !ok {
+		return "", fmt.Errorf("table %q is not tracked by history", table)
+	}
+
+	q := `(
+		WITH first_change AS (
+			SELECT DISTINCT ON (record_id) record_id, op
+			FROM {changes}
+			WHERE table_name = '{table}' AND changed_at > {asof}
+			ORDER BY record_id, changed_at, change_id
+		),
+		old_values AS (
+			SELECT record_id, jsonb_object_agg(field, to_jsonb(old_value)) AS vals
+			FROM (
+				SELECT DISTINCT ON (c.record_id, f.field) c.record_id, f.field, f.old_value
+				FROM {changes} c
+				JOIN {fields} f ON f.change_id = c.change_id
+				WHERE c.table_name = '{table}' AND c.changed_at > {asof} AND c.op <> 'INSERT'
+				ORDER BY c.record_id, f.field, c.changed_at, c.change_id
+			) v
+			GROUP BY record_id
+		)
+		SELECT cur.*
+		FROM {table} cur
+		WHERE NOT EXISTS (SELECT 1 FROM first_change fc WHERE fc.record_id = cur.{pk}::text)
+		UNION ALL
+		SELECT r.*
+		FROM first_change fc
+		LEFT JOIN {table} cur ON cur.{pk}::text = fc.record_id
+		LEFT JOIN old_values ov ON ov.record_id = fc.record_id
+		CROSS JOIN LATERAL jsonb_populate_record(
+			NULL::{table},
+			COALESCE(to_jsonb(cur), '{}'::jsonb) || COALESCE(ov.vals, '{}'::jsonb)
+		) r
+		WHERE fc.op <> 'INSERT'
+	) AS {table}`
+
+	return strings.NewReplacer(
+		"{changes}", ChangesTable,
+		"{fields}", FieldsTable,
+		"{table}", t.Table,
+		"{pk}", t.PrimaryKey,
+		"{asof}", asOfPH,
+	).Replace(q), nil
+}
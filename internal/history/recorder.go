@@ -0,0 +1,151 @@
+// internal/history/recorder.go
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"mini-poc-02/backend/internal/cdc"
+)
+
+// Recorder menulis histori dari event Debezium (before/after), dipasang sebagai cdc.Ingestor.OnEvent.
+//
+// Catatan: nilai disimpan apa adanya dari JSON Debezium (format bergantung converter), jadi
+// hanya cocok untuk tampilan /history; rekonstruksi as_of (AsOfSource) butuh mode trigger.
+type Recorder struct {
+	DB *sql.DB
+}
+
+// FieldChange adalah nilai lama/baru satu kolom (nil = NULL / tidak ada).
+type FieldChange struct {
+	Field    string
+	OldValue *string
+	NewValue *string
+}
+
+// RecordEvent mencatat satu event Debezium. Snapshot, truncate dan tabel yang tidak dilacak diabaikan.
+func (rc *Recorder) RecordEvent(ctx context.Context, ev cdc.Event) error {
+	t, ok := trackedTable(ev.Table)
+	if !ok || ev.IsSnapshot() {
+		return nil
+	}
+
+	var op string
+	switch ev.Op {
+	case cdc.OpCreate:
+		op = "INSERT"
+	case cdc.OpUpdate:
+		op = "UPDATE"
+	case cdc.OpDelete:
+		op = "DELETE"
+	default:
+		return nil
+	}
+
+	before, err := ev.BeforeMap()
+	if err != nil {
+		return fmt.Errorf("decode before: %w", err)
+	}
+	after, err := ev.AfterMap()
+	if err != nil {
+		return fmt.Errorf("decode after: %w", err)
+	}
+
+	rec := after
+	if op == "DELETE" {
+		rec = before
+	}
+	customerID := stringValue(rec["customer_id"])
+	recordID := stringValue(rec[t.PrimaryKey])
+	if customerID == nil || recordID == nil {
+		return fmt.Errorf("%s event without customer_id/%s", t.Table, t.PrimaryKey)
+	}
+
+	changes := DiffRows(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	tx, err := rc.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var changeID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO `+ChangesTable+` (customer_id, table_name, record_id, op, changed_at, source)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING change_id`,
+		*customerID, t.Table, *recordID, op, ev.SourceTime, SourceDebezium,
+	).Scan(&changeID)
+	if err != nil {
+		return fmt.Errorf("insert %s: %w", ChangesTable, err)
+	}
+
+	for _, c := range changes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO `+FieldsTable+` (change_id, field, old_value, new_value)
+			VALUES ($1, $2, $3, $4)`,
+			changeID, c.Field, c.OldValue, c.NewValue,
+		); err != nil {
+			return fmt.Errorf("insert %s: %w", FieldsTable, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DiffRows mengembalikan kolom yang berbeda antara before dan after (urut nama kolom).
+// before nil = INSERT (semua kolom after), after nil = DELETE (semua kolom before).
+func DiffRows(before, after map[string]any) []FieldChange {
+	keys := map[string]struct{}{}
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+
+	out := make([]FieldChange, 0, len(keys))
+	for k := range keys {
+		oldV, newV := stringValue(before[k]), stringValue(after[k])
+		if equalPtr(oldV, newV) {
+			continue
+		}
+		out = append(out, FieldChange{Field: k, OldValue: oldV, NewValue: newV})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
+
+func stringValue(v any) *string {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		s = x
+	case json.Number:
+		s = x.String()
+	case float64:
+		s = strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(x)
+	default:
+		b, _ := json.Marshal(x)
+		s = string(b)
+	}
+	return &s
+}
+
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
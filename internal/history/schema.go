@@ -0,0 +1,139 @@
+// internal/history/schema.go
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Tabel histori di ODS (milik aplikasi, bukan hasil sink):
+//
+//	customer_changes       : satu baris per perubahan (INSERT/UPDATE/DELETE) sebuah record
+//	customer_change_fields : nilai lama/baru per kolom untuk perubahan tersebut
+//
+// INSERT menyimpan semua kolom (old_value NULL), DELETE menyimpan semua kolom (new_value NULL),
+// UPDATE hanya kolom yang berubah. Dengan begitu state record di titik waktu mana pun bisa
+// direkonstruksi dari state sekarang.
+const (
+	ChangesTable = "customer_changes"
+	FieldsTable  = "customer_change_fields"
+
+	SourceTrigger  = "trigger"
+	SourceDebezium = "debezium"
+)
+
+// TrackedTable adalah tabel yang perubahannya dicatat, beserta primary key-nya.
+type TrackedTable struct {
+	Table      string
+	PrimaryKey string
+}
+
+var TrackedTables = []TrackedTable{
+	{Table: "customers", PrimaryKey: "customer_id"},
+	{Table: "credit_applications", PrimaryKey: "application_id"},
+	{Table: "vehicle_ownership", PrimaryKey: "ownership_id"},
+}
+
+func trackedTable(name string) (TrackedTable, bool) {
+	for _, t := range TrackedTables {
+		if t.Table == name {
+			return t, true
+		}
+	}
+	return TrackedTable{}, false
+}
+
+// EnsureSchema membuat tabel histori kalau belum ada.
+func EnsureSchema(ctx context.Context, db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS ` + ChangesTable + ` (
+			change_id   BIGSERIAL PRIMARY KEY,
+			customer_id TEXT        NOT NULL,
+			table_name  TEXT        NOT NULL,
+			record_id   TEXT        NOT NULL,
+			op          TEXT        NOT NULL, -- INSERT | UPDATE | DELETE
+			changed_at  TIMESTAMPTZ NOT NULL,
+			source      TEXT        NOT NULL, -- trigger | debezium
+			recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + ChangesTable + `_customer_idx
+			ON ` + ChangesTable + ` (customer_id, changed_at)`,
+		`CREATE INDEX IF NOT EXISTS ` + ChangesTable + `_table_time_idx
+			ON ` + ChangesTable + ` (table_name, changed_at)`,
+		`CREATE INDEX IF NOT EXISTS ` + ChangesTable + `_record_idx
+			ON ` + ChangesTable + ` (table_name, record_id, changed_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + FieldsTable + ` (
+			change_id BIGINT NOT NULL REFERENCES ` + ChangesTable + ` (change_id) ON DELETE CASCADE,
+			field     TEXT   NOT NULL,
+			old_value TEXT,
+			new_value TEXT,
+			PRIMARY KEY (change_id, field)
+		)`,
+	}
+	for _, q := range stmts {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return fmt.Errorf("ensure history schema: %w", err)
+		}
+	}
+	return nil
+}
+
+const historyFunction = "mks_record_customer_change"
+
+// InstallTriggers memasang trigger yang mencatat diff per kolom setiap kali JDBC sink
+// menulis ke tabel yang dilacak. Alternatif dari ingest Debezium (pilih salah satu).
+func InstallTriggers(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	fn := fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$
+		DECLARE
+			old_j jsonb := '{}'::jsonb;
+			new_j jsonb := '{}'::jsonb;
+			rec   jsonb;
+			cid   bigint;
+		BEGIN
+			IF TG_OP <> 'INSERT' THEN old_j := to_jsonb(OLD); END IF;
+			IF TG_OP <> 'DELETE' THEN new_j := to_jsonb(NEW); END IF;
+			IF TG_OP = 'UPDATE' AND old_j = new_j THEN
+				RETURN NULL;
+			END IF;
+			IF TG_OP = 'DELETE' THEN rec := old_j; ELSE rec := new_j; END IF;
+
+			INSERT INTO %[2]s (customer_id, table_name, record_id, op, changed_at, source)
+			VALUES (rec ->> 'customer_id', TG_TABLE_NAME, rec ->> TG_ARGV[0], TG_OP, now(), '%[4]s')
+			RETURNING change_id INTO cid;
+
+			INSERT INTO %[3]s (change_id, field, old_value, new_value)
+			SELECT cid, k, old_j ->> k, new_j ->> k
+			FROM (SELECT jsonb_object_keys(old_j || new_j) AS k) keys
+			WHERE (old_j -> k) IS DISTINCT FROM (new_j -> k);
+
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`, historyFunction, ChangesTable, FieldsTable, SourceTrigger)
+	if _, err := tx.ExecContext(ctx, fn); err != nil {
+		return fmt.Errorf("create history function: %w", err)
+	}
+
+	for _, t := range TrackedTables {
+		trigger := historyFunction + "_" + t.Table
+		stmts := []string{
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, trigger, t.Table),
+			fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s
+				FOR EACH ROW EXECUTE FUNCTION %s('%s')`, trigger, t.Table, historyFunction, t.PrimaryKey),
+		}
+		for _, q := range stmts {
+			if _, err := tx.ExecContext(ctx, q); err != nil {
+				return fmt.Errorf("install history trigger on %s: %w", t.Table, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,150 @@
+// internal/httpapi/access_audit.go
+package httpapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/logging"
+)
+
+// AccessAudit mencatat siapa melihat data customer mana (lihat audit.Table).
+type AccessAudit struct {
+	Log *audit.Log
+
+	// FailOpen: kalau pencatatan gagal, data tetap dilayani (hanya di-log).
+	// Default false: tanpa bukti akses, data tidak dikirim (503).
+	FailOpen bool
+}
+
+// recordAccess mencatat satu akses sebelum data dikirim. ok=false berarti pencatatan gagal
+// dan response 503 sudah ditulis. Parameter query ikut dicatat (kecuali access_token);
+// extra menambah/menimpa params.
+func (h *Handlers) recordAccess(w http.ResponseWriter, r *http.Request, action string, customerIDs []string, extra map[string]any) bool {
+	if h.Audit == nil {
+		return true
+	}
+
+	e := audit.Entry{
+		Action:      action,
+		Subject:     "anonymous",
+		RequestID:   middleware.GetReqID(r.Context()),
+		IP:          clientIP(r),
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Params:      map[string]any{},
+		CustomerIDs: customerIDs,
+	}
+	if p := auth.PrincipalFrom(r.Context()); p != nil {
+		e.Subject, e.Issuer, e.Roles = p.Subject, p.Issuer, p.Roles
+	}
+	for k, v := range r.URL.Query() {
+		if k == "access_token" {
+			continue
+		}
+		if len(v) == 1 {
+			e.Params[k] = v[0]
+		} else {
+			e.Params[k] = v
+		}
+	}
+	for k, v := range extra {
+		e.Params[k] = v
+	}
+
+	// context terpisah: pencatatan tetap selesai walau klien memutus koneksi
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 3*time.Second)
+	defer cancel()
+	if err := h.Audit.Log.Record(ctx, e); err != nil {
+		logging.FromContext(r.Context()).Error("access audit: record failed", "action", action, "error", err)
+		if !h.Audit.FailOpen {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "access audit unavailable; data not served"})
+			return false
+		}
+	}
+	return true
+}
+
+// clientIP: RemoteAddr sudah diganti Handlers.RealIP kalau request lewat proxy terpercaya.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+type AuditResponse struct {
+	Entries []audit.Entry `json:"entries"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+	Total   int           `json:"total"`
+}
+
+// GetAudit serves:
+//
+//	GET /api/v1/audit?limit=50&offset=0
+//
+// plus optional filters:
+//
+//	subject, action, customer_id, request_id
+//	from, to (RFC3339 atau YYYY-MM-DD)
+func (h *Handlers) GetAudit(w http.ResponseWriter, r *http.Request) {
+	if h.Audit == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "access audit is disabled (set AUDIT_ENABLED)"})
+		return
+	}
+
+	limit := queryInt(r, "limit", 50)
+	offset := queryInt(r, "offset", 0)
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	f := audit.Filter{
+		Subject:    strings.TrimSpace(r.URL.Query().Get("subject")),
+		Action:     strings.TrimSpace(r.URL.Query().Get("action")),
+		CustomerID: strings.TrimSpace(r.URL.Query().Get("customer_id")),
+		RequestID:  strings.TrimSpace(r.URL.Query().Get("request_id")),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if r.URL.Query().Get(key) == "" {
+			continue
+		}
+		t, err := parseTimeParam(r.URL.Query().Get(key), time.Time{})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid "+key, err)
+			return
+		}
+		*dst = &t
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+	defer cancel()
+
+	entries, total, err := h.Audit.Log.Query(ctx, f)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query access audit failed", err)
+		return
+	}
+
+	// membaca log audit juga akses yang dicatat
+	if !h.recordAccess(w, r, audit.ActionAuditView, nil, nil) {
+		return
+	}
+	writeJSON(w, http.StatusOK, AuditResponse{Entries: entries, Limit: limit, Offset: offset, Total: total})
+}
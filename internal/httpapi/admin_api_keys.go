@@ -0,0 +1,241 @@
+// internal/httpapi/admin_api_keys.go
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mini-poc-02/backend/internal/apikey"
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/logging"
+	"mini-poc-02/backend/internal/rbac"
+)
+
+// APIKeyAdmin: endpoint admin untuk API key service-to-service.
+type APIKeyAdmin struct {
+	Store      *apikey.Store
+	DefaultTTL time.Duration // dipakai kalau request tidak menyebut expires_at / expires_in
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`               // permission RBAC, mis. "customers:list" atau "stats:*"
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // atau expires_in
+	ExpiresIn string     `json:"expires_in,omitempty"` // durasi Go, mis. "720h"
+}
+
+type CreateAPIKeyResponse struct {
+	Key    apikey.Key `json:"key"`
+	APIKey string     `json:"api_key"` // plaintext, hanya ditampilkan sekali
+	Note   string     `json:"note"`
+}
+
+// CreateAPIKey serves:
+//
+//	POST /api/v1/admin/api-keys
+//	{"name": "bi-nightly", "scopes": ["customers:list", "stats:read"], "expires_in": "720h"}
+func (h *Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !h.apiKeysEnabled(w) {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "name is required"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "scopes is required", "permissions": rbac.Permissions})
+		return
+	}
+	for _, s := range req.Scopes {
+		if !validAPIKeyScope(s) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid scope " + s, "permissions": rbac.Permissions})
+			return
+		}
+	}
+	rowScopes, denied := h.apiKeyGrants(auth.PrincipalFrom(r.Context()), req.Scopes)
+	if len(denied) > 0 {
+		rbac.WriteForbidden(w, rbac.Forbidden{
+			Code:        "scope_not_held",
+			Permissions: denied,
+			Message:     "cannot grant scope(s) the caller does not hold: " + strings.Join(denied, ", "),
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(h.APIKeys.DefaultTTL)
+	switch {
+	case req.ExpiresAt != nil && req.ExpiresIn != "":
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "use either expires_at or expires_in"})
+		return
+	case req.ExpiresAt != nil:
+		expiresAt = req.ExpiresAt.UTC()
+	case req.ExpiresIn != "":
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "expires_in must be a positive duration, e.g. 720h"})
+			return
+		}
+		expiresAt = now.Add(d)
+	}
+	if !expiresAt.After(now) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "expiry must be in the future"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	createdBy := principalSubject(r)
+	k, plaintext, err := h.APIKeys.Store.Create(ctx, req.Name, req.Scopes, rowScopes, &expiresAt, createdBy)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "create API key failed", err)
+		return
+	}
+	logging.FromContext(r.Context()).Info("api key created",
+		"key_id", k.ID, "name", k.Name, "scopes", k.Scopes, "row_scopes", k.RowScopes, "expires_at", expiresAt, "by", createdBy)
+
+	writeJSON(w, http.StatusCreated, CreateAPIKeyResponse{
+		Key:    k,
+		APIKey: plaintext,
+		Note:   "store this key now; it cannot be retrieved again",
+	})
+}
+
+// ListAPIKeys serves:
+//
+//	GET /api/v1/admin/api-keys
+func (h *Handlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !h.apiKeysEnabled(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.APIKeys.Store.List(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "list API keys failed", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": keys})
+}
+
+// RevokeAPIKey serves:
+//
+//	DELETE /api/v1/admin/api-keys/{key_id}
+func (h *Handlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !h.apiKeysEnabled(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id := strings.TrimSpace(chi.URLParam(r, "key_id"))
+	k, err := h.APIKeys.Store.Revoke(ctx, id)
+	if errors.Is(err, apikey.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "revoke API key failed", err)
+		return
+	}
+	logging.FromContext(r.Context()).Info("api key revoked", "key_id", k.ID, "name", k.Name, "by", principalSubject(r))
+	writeJSON(w, http.StatusOK, map[string]any{"key": k})
+}
+
+func (h *Handlers) apiKeysEnabled(w http.ResponseWriter) bool {
+	if h.APIKeys == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "API keys are disabled (set API_KEYS_ENABLED)"})
+		return false
+	}
+	return true
+}
+
+// validAPIKeyScope: permission yang dikenal atau "<grup>:*". Key tidak boleh mengelola key lain
+// dan tidak boleh "*", supaya key yang bocor tidak bisa dipakai menerbitkan key baru.
+func validAPIKeyScope(scope string) bool {
+	if scope == "*" || strings.HasPrefix(scope, "apikeys:") {
+		return false
+	}
+	for _, p := range rbac.Permissions {
+		if p == scope {
+			return true
+		}
+		if group, _, _ := strings.Cut(p, ":"); scope == group+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyGrants memeriksa scope key terhadap hak pembuatnya, supaya pemegang apikeys:manage
+// tidak bisa menerbitkan key yang lebih luas dari dirinya sendiri. denied = scope yang tidak
+// dimiliki ("<grup>:*" hanya boleh kalau semua permission di grup itu dimiliki); rowScopes =
+// batasan baris pembuat per permission, disimpan di key. RBAC nil = semua boleh tanpa batasan.
+func (h *Handlers) apiKeyGrants(creator *auth.Principal, scopes []string) (rowScopes map[string][]map[string]string, denied []string) {
+	if h.RBAC == nil {
+		return nil, nil
+	}
+	for _, s := range scopes {
+		ok := true
+		for _, perm := range expandAPIKeyScope(s) {
+			d := h.RBAC.Decide(creator, perm)
+			if !d.Allowed {
+				ok = false
+				break
+			}
+			if d.Scopes == nil {
+				continue
+			}
+			if rowScopes == nil {
+				rowScopes = map[string][]map[string]string{}
+			}
+			rowScopes[perm] = nil
+			for _, rs := range d.Scopes {
+				rowScopes[perm] = append(rowScopes[perm], map[string]string(rs))
+			}
+		}
+		if !ok {
+			denied = append(denied, s)
+		}
+	}
+	return rowScopes, denied
+}
+
+// expandAPIKeyScope menjabarkan "<grup>:*" menjadi permission konkret di grup itu.
+func expandAPIKeyScope(scope string) []string {
+	group, ok := strings.CutSuffix(scope, ":*")
+	if !ok {
+		return []string{scope}
+	}
+	var out []string
+	for _, p := range rbac.Permissions {
+		if strings.HasPrefix(p, group+":") {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func principalSubject(r *http.Request) string {
+	if p := auth.PrincipalFrom(r.Context()); p != nil {
+		return p.Subject
+	}
+	return "anonymous"
+}
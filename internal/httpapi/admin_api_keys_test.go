@@ -0,0 +1,105 @@
+// internal/httpapi/admin_api_keys_test.go
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mini-poc-02/backend/internal/apikey"
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/rbac"
+)
+
+func TestCreateAPIKeyRejectsScopesNotHeld(t *testing.T) {
+	enforcer, err := rbac.NewEnforcer(rbac.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// key admin yang hanya boleh mengelola key dan membaca stats
+	enforcer.Policy.Roles["keyadmin"] = rbac.Role{Permissions: []string{rbac.PermAPIKeysManage, rbac.PermStatsRead}}
+
+	tests := []struct {
+		name       string
+		scopes     []string
+		wantDenied []string
+	}{
+		{name: "permission not held", scopes: []string{"stats:read", "customers:reveal"}, wantDenied: []string{"customers:reveal"}},
+		{name: "wildcard wider than caller", scopes: []string{"customers:*", "audit:read"}, wantDenied: []string{"customers:*", "audit:read"}},
+		{name: "group wildcard partly held", scopes: []string{"stats:*", "sync:*"}, wantDenied: []string{"sync:*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Store tanpa DB: request yang lolos pengecekan akan panic
+			h := &Handlers{RBAC: enforcer, APIKeys: &APIKeyAdmin{Store: &apikey.Store{}}}
+
+			body, _ := json.Marshal(CreateAPIKeyRequest{Name: "bi-nightly", Scopes: tt.scopes})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", strings.NewReader(string(body)))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: "u1", Roles: []string{"keyadmin"}}))
+			rec := httptest.NewRecorder()
+
+			h.CreateAPIKey(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403 (body %s)", rec.Code, rec.Body)
+			}
+			var resp struct {
+				Forbidden rbac.Forbidden `json:"forbidden"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp.Forbidden.Permissions, tt.wantDenied) {
+				t.Errorf("denied = %v, want %v", resp.Forbidden.Permissions, tt.wantDenied)
+			}
+		})
+	}
+}
+
+func TestAPIKeyGrants(t *testing.T) {
+	enforcer, err := rbac.NewEnforcer(rbac.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := &auth.Principal{Subject: "root", Roles: []string{"admin"}}
+	ops := &auth.Principal{Subject: "ops1", Roles: []string{"ops"}}
+	branch := &auth.Principal{Subject: "jabar1", Roles: []string{"branch_province"}, Claims: map[string]any{"province": "Jawa Barat"}}
+
+	tests := []struct {
+		name          string
+		h             *Handlers
+		creator       *auth.Principal
+		scopes        []string
+		wantRowScopes map[string][]map[string]string
+		wantDenied    []string
+	}{
+		{name: "admin", h: &Handlers{RBAC: enforcer}, creator: admin, scopes: []string{"customers:*", "audit:read"}},
+		{name: "ops", h: &Handlers{RBAC: enforcer}, creator: ops, scopes: []string{"sync:*", "metrics:read"}},
+		{name: "RBAC disabled", h: &Handlers{}, scopes: []string{"customers:reveal"}},
+		{
+			name:    "branch staff key inherits row scope",
+			h:       &Handlers{RBAC: enforcer},
+			creator: branch,
+			scopes:  []string{"customers:list", "customers:profile"},
+			wantRowScopes: map[string][]map[string]string{
+				"customers:list":    {{"province": "Jawa Barat"}},
+				"customers:profile": {{"province": "Jawa Barat"}},
+			},
+		},
+		{name: "branch staff cannot reveal", h: &Handlers{RBAC: enforcer}, creator: branch, scopes: []string{"customers:reveal"}, wantDenied: []string{"customers:reveal"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rowScopes, denied := tt.h.apiKeyGrants(tt.creator, tt.scopes)
+			if !reflect.DeepEqual(denied, tt.wantDenied) {
+				t.Errorf("denied = %v, want %v", denied, tt.wantDenied)
+			}
+			if !reflect.DeepEqual(rowScopes, tt.wantRowScopes) {
+				t.Errorf("row scopes = %v, want %v", rowScopes, tt.wantRowScopes)
+			}
+		})
+	}
+}
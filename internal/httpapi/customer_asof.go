@@ -0,0 +1,260 @@
+// internal/httpapi/customer_asof.go
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/history"
+)
+
+// readAsOf membaca parameter waktu point-in-time. nil berarti data live.
+// Tanggal polos (YYYY-MM-DD) diartikan akhir hari itu (UTC), karena auditor biasanya bertanya
+// "seperti apa datanya pada tanggal X". ok=false berarti response error sudah ditulis.
+func (h *Handlers) readAsOf(w http.ResponseWriter, r *http.Request, key string) (*time.Time, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get(key))
+	if raw == "" {
+		return nil, true
+	}
+	if !h.HistoryEnabled {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": key + " requires change history (set HISTORY_MODE)"})
+		return nil, false
+	}
+	if !h.HistoryAsOf {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": key + " is not supported with HISTORY_MODE=debezium (history values keep Debezium encodings); use HISTORY_MODE=trigger"})
+		return nil, false
+	}
+
+	t, err := parseTimeParam(raw, time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+key, err)
+		return nil, false
+	}
+	if len(raw) == len("2006-01-02") {
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+	return &t, true
+}
+
+// tableFrom mengembalikan ekspresi FROM untuk table: nama tabel live, atau subquery
+// rekonstruksi histori kalau asOf diisi (waktu ditambahkan sebagai argumen berikutnya).
+func (h *Handlers) tableFrom(table string, asOf *time.Time, args []any) (string, []any, error) {
+	if asOf == nil {
+		return table, args, nil
+	}
+	args = append(args, *asOf)
+	src, err := history.AsOfSource(table, fmt.Sprintf("$%d", len(args)))
+	if err != nil {
+		return "", nil, err
+	}
+	return src, args, nil
+}
+
+type RecordDiff struct {
+	Table    string      `json:"table"`
+	RecordID string      `json:"record_id"`
+	Change   string      `json:"change"` // added | removed | changed
+	Fields   []FieldDiff `json:"fields"`
+}
+
+type CustomerProfileDiffResponse struct {
+	CustomerID string       `json:"customer_id"`
+	From       time.Time    `json:"from"`
+	To         *time.Time   `json:"to"` // null = data live
+	Changes    []RecordDiff `json:"changes"`
+}
+
+// GetCustomerProfileDiff serves:
+//
+//	GET /api/v1/customers/{customer_id}/profile/diff?from=2024-01-01&to=2024-06-30
+//
+// Membandingkan profile 360 di dua titik waktu (to kosong = data live). Record yang
+// hanya ada di salah satu sisi dilaporkan added/removed; sisanya per kolom yang berubah.
+func (h *Handlers) GetCustomerProfileDiff(w http.ResponseWriter, r *http.Request) {
+	customerID := readCustomerID(r)
+	if customerID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "customer_id is required"})
+		return
+	}
+	if strings.TrimSpace(r.URL.Query().Get("from")) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "from is required"})
+		return
+	}
+
+	from, ok := h.readAsOf(w, r, "from")
+	if !ok {
+		return
+	}
+	to, ok := h.readAsOf(w, r, "to")
+	if !ok {
+		return
+	}
+	if to != nil && to.Before(*from) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "to must not be before from"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	before, errBefore := h.buildProfile(ctx, customerID, from)
+	after, errAfter := h.buildProfile(ctx, customerID, to)
+	for _, err := range []error{errBefore, errAfter} {
+		if err != nil && err != sql.ErrNoRows {
+			if !writeCustomerAccessError(w, err) {
+				writeQueryError(w, err)
+			}
+			return
+		}
+	}
+	if errBefore == sql.ErrNoRows && errAfter == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "customer not found"})
+		return
+	}
+
+	var changes []RecordDiff
+	add := func(table, key string, a, b any) error {
+		am, err := recordsByKey(a, key)
+		if err != nil {
+			return err
+		}
+		bm, err := recordsByKey(b, key)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, diffRecords(table, am, bm)...)
+		return nil
+	}
+
+	var custBefore, custAfter []CustomerDetail
+	if errBefore == nil {
+		custBefore = []CustomerDetail{before.Customer}
+	}
+	if errAfter == nil {
+		custAfter = []CustomerDetail{after.Customer}
+	}
+	for _, err := range []error{
+		add("customers", "customer_id", custBefore, custAfter),
+		add("credit_applications", "application_id", before.CreditApplications, after.CreditApplications),
+		add("vehicle_ownership", "ownership_id", before.VehicleOwnership, after.VehicleOwnership),
+	} {
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "diff profile failed", err)
+			return
+		}
+	}
+	if changes == nil {
+		changes = []RecordDiff{}
+	}
+
+	if !h.recordAccess(w, r, audit.ActionProfileDiff, []string{customerID}, nil) {
+		return
+	}
+	h.writeCustomerJSON(w, r, http.StatusOK, &CustomerProfileDiffResponse{
+		CustomerID: customerID,
+		From:       *from,
+		To:         to,
+		Changes:    changes,
+	})
+}
+
+// recordsByKey mengubah slice struct menjadi map record_id -> (field json -> nilai),
+// supaya dua snapshot bisa dibandingkan per kolom dengan nama yang sama seperti di response.
+func recordsByKey(rows any, key string) (map[string]map[string]any, error) {
+	raw, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var list []map[string]any
+	if err := dec.Decode(&list); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]map[string]any, len(list))
+	for _, m := range list {
+		out[fmt.Sprint(m[key])] = m
+	}
+	return out, nil
+}
+
+func diffRecords(table string, before, after map[string]map[string]any) []RecordDiff {
+	ids := make([]string, 0, len(before)+len(after))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var out []RecordDiff
+	for _, id := range ids {
+		b, inBefore := before[id]
+		a, inAfter := after[id]
+
+		d := RecordDiff{Table: table, RecordID: id, Change: "changed"}
+		switch {
+		case !inBefore:
+			d.Change = "added"
+		case !inAfter:
+			d.Change = "removed"
+		}
+
+		fields := make([]string, 0, len(a)+len(b))
+		seen := map[string]bool{}
+		for _, m := range []map[string]any{b, a} {
+			for f := range m {
+				if !seen[f] {
+					seen[f] = true
+					fields = append(fields, f)
+				}
+			}
+		}
+		sort.Strings(fields)
+
+		d.Fields = []FieldDiff{}
+		for _, f := range fields {
+			oldV, newV := diffValue(b, f), diffValue(a, f)
+			if equalStringPtr(oldV, newV) {
+				continue
+			}
+			d.Fields = append(d.Fields, FieldDiff{Field: f, OldValue: oldV, NewValue: newV})
+		}
+		if d.Change == "changed" && len(d.Fields) == 0 {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func diffValue(m map[string]any, field string) *string {
+	v, ok := m[field]
+	if !ok || v == nil {
+		return nil
+	}
+	if s, ok := v.(string); ok {
+		return &s
+	}
+	s := fmt.Sprint(v)
+	return &s
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,89 @@
+// internal/httpapi/customer_events.go
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/changefeed"
+)
+
+// StreamCustomerEvents serves:
+//
+//	GET /api/v1/customers/{customer_id}/events   (text/event-stream)
+//
+// Mengirim event "change" setiap kali baris customers / credit_applications /
+// vehicle_ownership milik customer ini berubah di ODS (sumber: LISTEN/NOTIFY).
+func (h *Handlers) StreamCustomerEvents(w http.ResponseWriter, r *http.Request) {
+	customerID := readCustomerID(r)
+	if customerID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "customer_id is required"})
+		return
+	}
+	if h.ChangeFeed == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "customer change feed is disabled"})
+		return
+	}
+
+	if err := h.checkCustomerScope(r.Context(), customerID); err != nil {
+		if !writeCustomerAccessError(w, err) {
+			writeError(w, http.StatusInternalServerError, "check customer scope failed", err)
+		}
+		return
+	}
+
+	if !h.recordAccess(w, r, audit.ActionEventsSubscribe, []string{customerID}, nil) {
+		return
+	}
+
+	events, cancel, err := h.ChangeFeed.Subscribe(customerID)
+	if errors.Is(err, changefeed.ErrTooManySubscribers) {
+		w.Header().Set("Retry-After", "10")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "subscribe failed", err)
+		return
+	}
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	hb := time.NewTicker(15 * time.Second)
+	defer hb.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return // terlalu lambat; client reconnect otomatis
+			}
+			data, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "id: %d\nevent: change\ndata: %s\n\n", ev.ID, data)
+		case t := <-hb.C:
+			fmt.Fprintf(w, ": ping %s\n\n", t.UTC().Format(time.RFC3339))
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// internal/httpapi/customer_history.go
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/history"
+)
+
+type FieldDiff struct {
+	Field    string  `json:"field"`
+	OldValue *string `json:"old_value"`
+	NewValue *string `json:"new_value"`
+}
+
+type CustomerChange struct {
+	ChangeID  int64       `json:"change_id"`
+	Table     string      `json:"table"`
+	RecordID  string      `json:"record_id"`
+	Op        string      `json:"op"` // INSERT | UPDATE | DELETE
+	ChangedAt time.Time   `json:"changed_at"`
+	Source    string      `json:"source"` // trigger | debezium
+	Fields    []FieldDiff `json:"fields"`
+}
+
+type CustomerHistoryResponse struct {
+	CustomerID string           `json:"customer_id"`
+	Changes    []CustomerChange `json:"changes"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	Total      int              `json:"total"`
+}
+
+// GetCustomerHistory serves:
+//
+//	GET /api/v1/customers/{customer_id}/history?limit=50&offset=0
+//
+// plus optional filters:
+//
+//	table=customers|credit_applications|vehicle_ownership
+//	field=phone_number (hanya perubahan yang menyentuh kolom ini)
+//	from, to (RFC3339 atau YYYY-MM-DD)
+//
+// Urutan: perubahan terbaru lebih dulu.
+func (h *Handlers) GetCustomerHistory(w http.ResponseWriter, r *http.Request) {
+	customerID := readCustomerID(r)
+	if customerID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "customer_id is required"})
+		return
+	}
+
+	limit := queryInt(r, "limit", 50)
+	offset := queryInt(r, "offset", 0)
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	where := []string{"c.customer_id = $1"}
+	args := []any{customerID}
+	addArg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if table := strings.TrimSpace(r.URL.Query().Get("table")); table != "" {
+		where = append(where, "c.table_name = "+addArg(table))
+	}
+	if field := strings.TrimSpace(r.URL.Query().Get("field")); field != "" {
+		where = append(where, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s f WHERE f.change_id = c.change_id AND f.field = %s)",
+			history.FieldsTable, addArg(field)))
+	}
+	if s := r.URL.Query().Get("from"); s != "" {
+		from, err := parseTimeParam(s, time.Time{})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid from", err)
+			return
+		}
+		where = append(where, "c.changed_at >= "+addArg(from))
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		to, err := parseTimeParam(s, time.Time{})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid to", err)
+			return
+		}
+		where = append(where, "c.changed_at < "+addArg(to))
+	}
+	whereSQL := "WHERE " + strings.Join(where, " AND ")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.checkCustomerScope(ctx, customerID); err != nil {
+		if !writeCustomerAccessError(w, err) {
+			writeError(w, http.StatusInternalServerError, "check customer scope failed", err)
+		}
+		return
+	}
+
+	var total int
+	done := h.trackQuery(ctx, "countCustomerChanges")
+	err := h.DB.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT COUNT(1) FROM %s c %s`, history.ChangesTable, whereSQL), args...,
+	).Scan(&total)
+	done(1, err)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "count customer history failed", err)
+		return
+	}
+
+	limitPH := addArg(limit)
+	offsetPH := addArg(offset)
+	changes, err := h.getCustomerChanges(ctx, fmt.Sprintf(`
+		SELECT c.change_id, c.table_name, c.record_id, c.op, c.changed_at, c.source
+		FROM %s c
+		%s
+		ORDER BY c.changed_at DESC, c.change_id DESC
+		LIMIT %s OFFSET %s
+	`, history.ChangesTable, whereSQL, limitPH, offsetPH), args...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query customer history failed", err)
+		return
+	}
+
+	if !h.recordAccess(w, r, audit.ActionHistoryView, []string{customerID}, nil) {
+		return
+	}
+	h.writeCustomerJSON(w, r, http.StatusOK, &CustomerHistoryResponse{
+		CustomerID: customerID,
+		Changes:    changes,
+		Limit:      limit,
+		Offset:     offset,
+		Total:      total,
+	})
+}
+
+// getCustomerChanges menjalankan query header perubahan lalu memuat diff kolomnya.
+// Query harus memilih: change_id, table_name, record_id, op, changed_at, source.
+func (h *Handlers) getCustomerChanges(ctx context.Context, q string, args ...any) (changes []CustomerChange, err error) {
+	done := h.trackQuery(ctx, "getCustomerChanges")
+	defer func() { done(len(changes), err) }()
+
+	rows, err := h.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes = make([]CustomerChange, 0, 16)
+	index := map[int64]int{}
+	ids := make([]int64, 0, 16)
+	for rows.Next() {
+		var c CustomerChange
+		if err := rows.Scan(&c.ChangeID, &c.Table, &c.RecordID, &c.Op, &c.ChangedAt, &c.Source); err != nil {
+			return nil, err
+		}
+		c.Fields = []FieldDiff{}
+		index[c.ChangeID] = len(changes)
+		ids = append(ids, c.ChangeID)
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return changes, nil
+	}
+
+	frows, err := h.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT change_id, field, old_value, new_value
+		FROM %s
+		WHERE change_id = ANY($1)
+		ORDER BY change_id, field
+	`, history.FieldsTable), ids)
+	if err != nil {
+		return nil, err
+	}
+	defer frows.Close()
+
+	for frows.Next() {
+		var id int64
+		var f FieldDiff
+		var oldV, newV sql.NullString
+		if err := frows.Scan(&id, &f.Field, &oldV, &newV); err != nil {
+			return nil, err
+		}
+		if oldV.Valid {
+			f.OldValue = &oldV.String
+		}
+		if newV.Valid {
+			f.NewValue = &newV.String
+		}
+		if i, ok := index[id]; ok {
+			changes[i].Fields = append(changes[i].Fields, f)
+		}
+	}
+	return changes, frows.Err()
+}
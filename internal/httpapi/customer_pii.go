@@ -0,0 +1,170 @@
+// internal/httpapi/customer_pii.go
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/logging"
+)
+
+// writeCustomerJSON memasking PII sesuai role principal (lihat Handlers.PII) lalu menulis JSON.
+// Semua response yang memuat data customer harus lewat sini; v harus pointer.
+func (h *Handlers) writeCustomerJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	h.PII.Apply(r.Context(), v)
+	writeJSON(w, status, v)
+}
+
+// MaskPII: nama field diff baru diketahui saat runtime, jadi masking per baris.
+func (d *FieldDiff) MaskPII(mask func(field, value string) string) {
+	for _, v := range []**string{&d.OldValue, &d.NewValue} {
+		if *v != nil {
+			s := mask(d.Field, **v)
+			*v = &s
+		}
+	}
+}
+
+// MaskPII: details profile_changed berisi diff field seperti /history.
+func (e *TimelineEvent) MaskPII(mask func(field, value string) string) {
+	if e.Kind != TimelineProfileChanged || len(e.Details) == 0 {
+		return
+	}
+	var details struct {
+		Source string      `json:"source"`
+		Fields []FieldDiff `json:"fields"`
+	}
+	if err := json.Unmarshal(e.Details, &details); err != nil {
+		e.Details = json.RawMessage(`null`) // jangan kirim nilai yang tidak bisa dimasking
+		return
+	}
+	for i := range details.Fields {
+		details.Fields[i].MaskPII(mask)
+	}
+	if b, err := json.Marshal(details); err == nil {
+		e.Details = b
+	}
+}
+
+type RevealRequest struct {
+	Fields []string `json:"fields"` // kosong = semua field yang dimasking untuk role pemanggil
+	Reason string   `json:"reason"` // wajib, ikut dicatat di log
+}
+
+type RevealResponse struct {
+	CustomerID string             `json:"customer_id"`
+	Fields     map[string]*string `json:"fields"`
+	Reason     string             `json:"reason"`
+	RevealedAt time.Time          `json:"revealed_at"`
+}
+
+// RevealCustomerPII serves:
+//
+//	POST /api/v1/customers/{customer_id}/reveal
+//	{"fields": ["nik", "phone_number"], "reason": "verifikasi klaim #123"}
+//
+// Mengembalikan nilai utuh field yang biasanya dimasking. Setiap reveal dicatat.
+func (h *Handlers) RevealCustomerPII(w http.ResponseWriter, r *http.Request) {
+	customerID := readCustomerID(r)
+	if customerID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "customer_id is required"})
+		return
+	}
+	if h.PII == nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "PII masking is disabled; nothing to reveal"})
+		return
+	}
+	// reveal harus tercatat atas identitas tertentu: tanpa auth semua pemanggil anonim
+	if h.Auth == nil || auth.PrincipalFrom(r.Context()) == nil {
+		writeJSON(w, http.StatusForbidden, map[string]any{"error": "PII reveal requires an authenticated caller (set AUTH_ISSUER)"})
+		return
+	}
+
+	var req RevealRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "reason is required"})
+		return
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = h.PII.MaskedFields(r.Context())
+	}
+	for _, f := range req.Fields {
+		if _, ok := h.PII.Policy.Fields[f]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "field " + f + " is not a PII field"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	c, err := h.getCustomerInScope(ctx, customerID, nil)
+	if writeCustomerAccessError(w, err) {
+		return
+	}
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	values, err := customerFieldValues(c, req.Fields)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "cannot reveal field", err)
+		return
+	}
+
+	if !h.recordAccess(w, r, audit.ActionPIIReveal, []string{customerID}, map[string]any{
+		"fields": req.Fields,
+		"reason": req.Reason,
+	}) {
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("pii reveal",
+		"customer_id", customerID, "fields", req.Fields, "subject", principalSubject(r), "ip", clientIP(r), "reason", req.Reason)
+
+	writeJSON(w, http.StatusOK, RevealResponse{
+		CustomerID: customerID,
+		Fields:     values,
+		Reason:     req.Reason,
+		RevealedAt: time.Now().UTC(),
+	})
+}
+
+// customerFieldValues mengambil nilai field (nama JSON) dari CustomerDetail.
+func customerFieldValues(c CustomerDetail, fields []string) (map[string]*string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*string, len(fields))
+	for _, f := range fields {
+		v, ok := all[f]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a customer profile field", f)
+		}
+		if v == nil {
+			out[f] = nil
+			continue
+		}
+		s := fmt.Sprint(v)
+		out[f] = &s
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+// internal/httpapi/customer_pii_test.go
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/pii"
+)
+
+func TestRevealCustomerPIIRequiresAuthenticatedCaller(t *testing.T) {
+	masker, err := pii.NewMasker(pii.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := auth.NewVerifier(auth.NewKeySet("jwks.json", 0), auth.Config{Issuer: "https://idp.example", Audience: "mks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		auth *auth.Verifier
+	}{
+		{name: "auth disabled", auth: nil},
+		{name: "auth enabled without principal", auth: verifier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handlers{PII: masker, Auth: tt.auth}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("customer_id", "C001")
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/customers/C001/reveal", strings.NewReader(`{"reason":"verifikasi klaim #123"}`))
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+
+			// DB nil: request yang lolos pengecekan akan panic, jadi 403 juga membuktikan tidak ada query
+			h.RevealCustomerPII(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusForbidden, rec.Body)
+			}
+		})
+	}
+}
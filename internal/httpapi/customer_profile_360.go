@@ -289,11 +289,15 @@ package httpapi
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/rbac"
 )
 
 type CustomerDetail struct {
@@ -384,11 +388,16 @@ type CustomerProfile360Response struct {
 	CreditApplications []CreditApplication `json:"credit_applications"`
 	VehicleOwnership   []VehicleOwnership  `json:"vehicle_ownership"`
 	Summary            ProfileSummary      `json:"summary"`
+	AsOf               *time.Time          `json:"as_of,omitempty"` // diisi kalau profile direkonstruksi dari histori
 }
 
 // GetCustomerProfile serves:
 //
 //	GET /api/v1/customers/{customer_id}/profile
+//	GET /api/v1/customers/{customer_id}/profile?as_of=2024-06-30   (butuh HISTORY_MODE)
+//
+// as_of (RFC3339 atau YYYY-MM-DD = akhir hari itu, UTC) merekonstruksi profile dari histori
+// perubahan; 404 kalau customer belum ada pada waktu itu.
 func (h *Handlers) GetCustomerProfile(w http.ResponseWriter, r *http.Request) {
 	customerID := readCustomerID(r)
 	if customerID == "" {
@@ -396,32 +405,49 @@ func (h *Handlers) GetCustomerProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	asOf, ok := h.readAsOf(w, r, "as_of")
+	if !ok {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
 	defer cancel()
 
-	c, err := h.getCustomer(ctx, customerID)
-	if err == sql.ErrNoRows {
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "customer not found"})
+	resp, err := h.buildProfile(ctx, customerID, asOf)
+	if writeCustomerAccessError(w, err) {
 		return
 	}
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "query customer failed", err)
+		writeQueryError(w, err)
 		return
 	}
 
-	apps, err := h.getCreditApplications(ctx, customerID)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "query credit_applications failed", err)
+	if !h.recordAccess(w, r, audit.ActionProfileView, []string{customerID}, nil) {
 		return
 	}
+	h.writeCustomerJSON(w, r, http.StatusOK, &resp)
+}
 
-	vehicles, err := h.getVehicleOwnership(ctx, customerID)
+// buildProfile merakit profile 360 dari data live, atau dari rekonstruksi histori kalau asOf diisi.
+// sql.ErrNoRows dikembalikan apa adanya kalau customer tidak ada, errOutOfScope kalau customer
+// ada tapi di luar batasan baris RBAC.
+func (h *Handlers) buildProfile(ctx context.Context, customerID string, asOf *time.Time) (CustomerProfile360Response, error) {
+	c, err := h.getCustomerInScope(ctx, customerID, asOf)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "query vehicle_ownership failed", err)
-		return
+		return CustomerProfile360Response{}, err
 	}
 
-	sum, err := h.getProfileSummary(ctx, customerID, apps, vehicles)
+	apps, err := h.getCreditApplications(ctx, customerID, asOf)
+	if err != nil {
+		return CustomerProfile360Response{}, &queryError{Message: "query credit_applications failed", Err: err}
+	}
+
+	vehicles, err := h.getVehicleOwnership(ctx, customerID, asOf)
+	if err != nil {
+		return CustomerProfile360Response{}, &queryError{Message: "query vehicle_ownership failed", Err: err}
+	}
+
+	sum, err := h.getProfileSummary(ctx, customerID, apps, vehicles, asOf)
 	if err != nil {
 		// summary best-effort; tetap balikin data utama
 		sum = ProfileSummary{
@@ -430,28 +456,63 @@ func (h *Handlers) GetCustomerProfile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	writeJSON(w, http.StatusOK, CustomerProfile360Response{
+	return CustomerProfile360Response{
 		Customer:           c,
 		CreditApplications: apps,
 		VehicleOwnership:   vehicles,
 		Summary:            sum,
-	})
+		AsOf:               asOf,
+	}, nil
+}
+
+// getCustomerInScope: getCustomer dengan error yang sama seperti buildProfile
+// (sql.ErrNoRows, errOutOfScope, atau queryError).
+func (h *Handlers) getCustomerInScope(ctx context.Context, customerID string, asOf *time.Time) (CustomerDetail, error) {
+	c, err := h.getCustomer(ctx, customerID, asOf)
+	if err == sql.ErrNoRows {
+		if len(rbac.ScopesFrom(ctx)) > 0 {
+			// bedakan "tidak ada" vs "di luar scope" supaya 403 terstruktur, bukan 404 yang membingungkan
+			if serr := h.checkCustomerScope(ctx, customerID); serr != nil && serr != sql.ErrNoRows {
+				return CustomerDetail{}, serr
+			}
+		}
+		return CustomerDetail{}, err
+	}
+	if err != nil {
+		return CustomerDetail{}, &queryError{Message: "query customer failed", Err: err}
+	}
+	return c, nil
 }
 
-func (h *Handlers) getCustomer(ctx context.Context, customerID string) (CustomerDetail, error) {
-	const q = `
+func (h *Handlers) getCustomer(ctx context.Context, customerID string, asOf *time.Time) (c CustomerDetail, err error) {
+	done := h.trackQuery(ctx, "getCustomer")
+	defer func() { done(1, err) }()
+
+	args := []any{customerID}
+	scope := rbac.Predicate(ctx, "", func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	})
+	if scope != "" {
+		scope = " AND " + scope
+	}
+
+	from, args, err := h.tableFrom("customers", asOf, args)
+	if err != nil {
+		return CustomerDetail{}, err
+	}
+	q := `
 		SELECT
 			customer_id, nik, full_name, date_of_birth, gender, marital_status, phone_number, email,
 			address, city, province, postal_code, occupation, employer_name, monthly_income,
 			employment_status, years_of_employment, education_level,
 			emergency_contact_name, emergency_contact_phone, emergency_contact_relation,
 			credit_score, customer_segment, registration_date, last_updated, status
-		FROM customers
-		WHERE customer_id = $1
+		FROM ` + from + `
+		WHERE customer_id = $1` + scope + `
 	`
 
-	var c CustomerDetail
-	err := h.DB.QueryRowContext(ctx, q, customerID).Scan(
+	err = h.DB.QueryRowContext(ctx, q, args...).Scan(
 		&c.CustomerID, &c.NIK, &c.FullName, &c.DateOfBirth, &c.Gender, &c.MaritalStatus,
 		&c.PhoneNumber, &c.Email,
 		&c.Address, &c.City, &c.Province, &c.PostalCode,
@@ -463,25 +524,32 @@ func (h *Handlers) getCustomer(ctx context.Context, customerID string) (Customer
 	return c, err
 }
 
-func (h *Handlers) getCreditApplications(ctx context.Context, customerID string) ([]CreditApplication, error) {
-	const q = `
+func (h *Handlers) getCreditApplications(ctx context.Context, customerID string, asOf *time.Time) (apps []CreditApplication, err error) {
+	done := h.trackQuery(ctx, "getCreditApplications")
+	defer func() { done(len(apps), err) }()
+
+	from, args, err := h.tableFrom("credit_applications", asOf, []any{customerID})
+	if err != nil {
+		return nil, err
+	}
+	q := `
 		SELECT
 			application_id, customer_id, application_date, vehicle_type, vehicle_brand, vehicle_model, vehicle_year,
 			vehicle_price, down_payment, loan_amount, tenor_months, interest_rate, monthly_installment,
 			application_status, approval_date, rejection_reason, disbursement_date, first_installment_date,
 			last_payment_date, outstanding_amount, payment_status, collateral_status, notes, processed_by, approved_by, created_date
-		FROM credit_applications
+		FROM ` + from + `
 		WHERE customer_id = $1
 		ORDER BY application_date DESC
 	`
 
-	rows, err := h.DB.QueryContext(ctx, q, customerID)
+	rows, err := h.DB.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	apps := make([]CreditApplication, 0, 8)
+	apps = make([]CreditApplication, 0, 8)
 	for rows.Next() {
 		var a CreditApplication
 		if err := rows.Scan(
@@ -500,23 +568,30 @@ func (h *Handlers) getCreditApplications(ctx context.Context, customerID string)
 	return apps, rows.Err()
 }
 
-func (h *Handlers) getVehicleOwnership(ctx context.Context, customerID string) ([]VehicleOwnership, error) {
-	const q = `
+func (h *Handlers) getVehicleOwnership(ctx context.Context, customerID string, asOf *time.Time) (vehicles []VehicleOwnership, err error) {
+	done := h.trackQuery(ctx, "getVehicleOwnership")
+	defer func() { done(len(vehicles), err) }()
+
+	from, args, err := h.tableFrom("vehicle_ownership", asOf, []any{customerID})
+	if err != nil {
+		return nil, err
+	}
+	q := `
 		SELECT
 			ownership_id, customer_id, vehicle_type, brand, model, year, vehicle_price, purchase_date,
 			ownership_status, registration_number, chassis_number, engine_number, created_date
-		FROM vehicle_ownership
+		FROM ` + from + `
 		WHERE customer_id = $1
 		ORDER BY created_date DESC
 	`
 
-	rows, err := h.DB.QueryContext(ctx, q, customerID)
+	rows, err := h.DB.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	vehicles := make([]VehicleOwnership, 0, 4)
+	vehicles = make([]VehicleOwnership, 0, 4)
 	for rows.Next() {
 		var v VehicleOwnership
 		if err := rows.Scan(
@@ -533,8 +608,11 @@ func (h *Handlers) getVehicleOwnership(ctx context.Context, customerID string) (
 	return vehicles, rows.Err()
 }
 
-func (h *Handlers) getProfileSummary(ctx context.Context, customerID string, apps []CreditApplication, vehicles []VehicleOwnership) (ProfileSummary, error) {
-	sum := ProfileSummary{
+func (h *Handlers) getProfileSummary(ctx context.Context, customerID string, apps []CreditApplication, vehicles []VehicleOwnership, asOf *time.Time) (sum ProfileSummary, err error) {
+	done := h.trackQuery(ctx, "getProfileSummary")
+	defer func() { done(1, err) }()
+
+	sum = ProfileSummary{
 		TotalCreditApplications: len(apps),
 		TotalVehicleOwnership:   len(vehicles),
 	}
@@ -547,17 +625,21 @@ func (h *Handlers) getProfileSummary(ctx context.Context, customerID string, app
 
 	// Aggregate (best effort)
 	// Catatan: ini akan jalan bagus kalau loan_amount & interest_rate bertipe numeric.
-	const qAgg = `
+	from, args, err := h.tableFrom("credit_applications", asOf, []any{customerID})
+	if err != nil {
+		return sum, err
+	}
+	qAgg := `
 		SELECT
 			SUM(loan_amount)::text,
 			AVG(interest_rate)::text
-		FROM credit_applications
+		FROM ` + from + `
 		WHERE customer_id = $1
 	`
 
 	var sumLoan sql.NullString
 	var avgRate sql.NullString
-	if err := h.DB.QueryRowContext(ctx, qAgg, customerID).Scan(&sumLoan, &avgRate); err != nil {
+	if err := h.DB.QueryRowContext(ctx, qAgg, args...).Scan(&sumLoan, &avgRate); err != nil {
 		return sum, err
 	}
 
@@ -0,0 +1,57 @@
+// internal/httpapi/customer_scope.go
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"mini-poc-02/backend/internal/rbac"
+)
+
+// errOutOfScope: customer ada, tapi di luar batasan baris (province/city) milik principal.
+var errOutOfScope = errors.New("customer is outside your data scope")
+
+// checkCustomerScope memastikan customer boleh diakses principal request ini.
+// Mengembalikan sql.ErrNoRows kalau customer tidak ada, errOutOfScope kalau di luar scope.
+func (h *Handlers) checkCustomerScope(ctx context.Context, customerID string) error {
+	if len(rbac.ScopesFrom(ctx)) == 0 {
+		return nil
+	}
+
+	args := []any{customerID}
+	pred := rbac.Predicate(ctx, "", func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	})
+
+	done := h.trackQuery(ctx, "checkCustomerScope")
+	var inScope bool
+	err := h.DB.QueryRowContext(ctx,
+		`SELECT COALESCE(`+pred+`, false) FROM customers WHERE customer_id = $1`, args...,
+	).Scan(&inScope)
+	done(1, err)
+	if err != nil {
+		return err
+	}
+	if !inScope {
+		return errOutOfScope
+	}
+	return nil
+}
+
+// writeCustomerAccessError menulis response untuk error dari checkCustomerScope / buildProfile.
+// ok=false berarti err bukan error akses dan harus ditangani pemanggil.
+func writeCustomerAccessError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "customer not found"})
+	case errors.Is(err, errOutOfScope):
+		rbac.WriteForbidden(w, rbac.Forbidden{Code: "out_of_scope", Message: err.Error()})
+	default:
+		return false
+	}
+	return true
+}
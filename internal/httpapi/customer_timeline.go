@@ -0,0 +1,275 @@
+// internal/httpapi/customer_timeline.go
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/history"
+)
+
+// Jenis event timeline.
+const (
+	TimelineCustomerRegistered   = "customer_registered"
+	TimelineApplicationSubmitted = "application_submitted"
+	TimelineApplicationApproved  = "application_approved"
+	TimelineApplicationRejected  = "application_rejected"
+	TimelineApplicationDisbursed = "application_disbursed"
+	TimelineFirstInstallment     = "first_installment"
+	TimelineLastPayment          = "last_payment"
+	TimelineVehiclePurchased     = "vehicle_purchased"
+	TimelineProfileChanged       = "profile_changed" // butuh HISTORY_MODE
+)
+
+var timelineKinds = []string{
+	TimelineCustomerRegistered,
+	TimelineApplicationSubmitted,
+	TimelineApplicationApproved,
+	TimelineApplicationRejected,
+	TimelineApplicationDisbursed,
+	TimelineFirstInstallment,
+	TimelineLastPayment,
+	TimelineVehiclePurchased,
+	TimelineProfileChanged,
+}
+
+type TimelineEvent struct {
+	Kind     string          `json:"kind"`
+	At       time.Time       `json:"at"`
+	Table    string          `json:"table"`
+	RecordID string          `json:"record_id"`
+	Details  json.RawMessage `json:"details"` // isi tergantung kind
+}
+
+type CustomerTimelineResponse struct {
+	CustomerID string          `json:"customer_id"`
+	Events     []TimelineEvent `json:"events"`
+	Kinds      []string        `json:"kinds"` // kind yang tersedia di server ini
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+	Total      int             `json:"total"`
+}
+
+// timelineSources adalah potongan UNION ALL per kind. $1 = customer_id.
+// Tanggal bertipe DATE/TEXT di-cast ke timestamptz supaya bisa diurutkan bersama;
+// nama kolom diberikan di query luar (AS t(kind, at, ...)).
+var timelineSources = map[string]string{
+	TimelineCustomerRegistered: `
+		SELECT 'customer_registered', registration_date::timestamptz,
+		       'customers', customer_id::text,
+		       jsonb_build_object('segment', customer_segment, 'status', status)
+		FROM customers WHERE customer_id = $1`,
+	TimelineApplicationSubmitted: `
+		SELECT 'application_submitted', application_date::timestamptz,
+		       'credit_applications', application_id::text,
+		       jsonb_build_object('vehicle_brand', vehicle_brand, 'vehicle_model', vehicle_model,
+		                          'loan_amount', loan_amount::text, 'tenor_months', tenor_months)
+		FROM credit_applications WHERE customer_id = $1`,
+	// approval_date adalah tanggal keputusan: status Rejected => rejected, selain itu approved
+	TimelineApplicationApproved: `
+		SELECT 'application_approved', approval_date::timestamptz,
+		       'credit_applications', application_id::text,
+		       jsonb_build_object('application_status', application_status, 'approved_by', approved_by)
+		FROM credit_applications
+		WHERE customer_id = $1 AND approval_date IS NOT NULL AND lower(application_status) <> 'rejected'`,
+	TimelineApplicationRejected: `
+		SELECT 'application_rejected', approval_date::timestamptz,
+		       'credit_applications', application_id::text,
+		       jsonb_build_object('rejection_reason', rejection_reason, 'processed_by', processed_by)
+		FROM credit_applications
+		WHERE customer_id = $1 AND approval_date IS NOT NULL AND lower(application_status) = 'rejected'`,
+	TimelineApplicationDisbursed: `
+		SELECT 'application_disbursed', disbursement_date::timestamptz,
+		       'credit_applications', application_id::text,
+		       jsonb_build_object('loan_amount', loan_amount::text)
+		FROM credit_applications WHERE customer_id = $1 AND disbursement_date IS NOT NULL`,
+	TimelineFirstInstallment: `
+		SELECT 'first_installment', first_installment_date::timestamptz,
+		       'credit_applications', application_id::text,
+		       jsonb_build_object('monthly_installment', monthly_installment::text)
+		FROM credit_applications WHERE customer_id = $1 AND first_installment_date IS NOT NULL`,
+	TimelineLastPayment: `
+		SELECT 'last_payment', last_payment_date::timestamptz,
+		       'credit_applications', application_id::text,
+		       jsonb_build_object('payment_status', payment_status, 'outstanding_amount', outstanding_amount::text)
+		FROM credit_applications WHERE customer_id = $1 AND last_payment_date IS NOT NULL`,
+	TimelineVehiclePurchased: `
+		SELECT 'vehicle_purchased', purchase_date::timestamptz,
+		       'vehicle_ownership', ownership_id::text,
+		       jsonb_build_object('vehicle_type', vehicle_type, 'brand', brand, 'model', model, 'year', year,
+		                          'ownership_status', ownership_status)
+		FROM vehicle_ownership WHERE customer_id = $1 AND purchase_date IS NOT NULL`,
+	TimelineProfileChanged: `
+		SELECT 'profile_changed', c.changed_at,
+		       'customers', c.record_id,
+		       jsonb_build_object('source', c.source, 'fields', (
+		           SELECT jsonb_agg(jsonb_build_object('field', f.field, 'old_value', f.old_value, 'new_value', f.new_value) ORDER BY f.field)
+		           FROM ` + history.FieldsTable + ` f WHERE f.change_id = c.change_id))
+		FROM ` + history.ChangesTable + ` c
+		WHERE c.customer_id = $1 AND c.table_name = 'customers' AND c.op = 'UPDATE'`,
+}
+
+// GetCustomerTimeline serves:
+//
+//	GET /api/v1/customers/{customer_id}/timeline?limit=50&offset=0
+//
+// plus optional:
+//
+//	kinds=application_submitted,vehicle_purchased (default semua)
+//	order=desc|asc (default desc = terbaru dulu)
+//
+// Menggabungkan registrasi, tanggal-tanggal aplikasi kredit, pembelian kendaraan, dan
+// perubahan profile (kalau histori aktif) menjadi satu daftar kronologis.
+func (h *Handlers) GetCustomerTimeline(w http.ResponseWriter, r *http.Request) {
+	customerID := readCustomerID(r)
+	if customerID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "customer_id is required"})
+		return
+	}
+
+	limit := queryInt(r, "limit", 50)
+	offset := queryInt(r, "offset", 0)
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	order := "DESC"
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("order")), "asc") {
+		order = "ASC"
+	}
+
+	available := h.timelineKinds()
+	kinds := available
+	if raw := strings.TrimSpace(r.URL.Query().Get("kinds")); raw != "" {
+		kinds = nil
+		for _, k := range strings.Split(raw, ",") {
+			k = strings.TrimSpace(k)
+			if k == "" {
+				continue
+			}
+			if _, ok := timelineSources[k]; !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unknown kind " + k, "kinds": available})
+				return
+			}
+			if k == TimelineProfileChanged && !h.HistoryEnabled {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": k + " requires change history (set HISTORY_MODE)"})
+				return
+			}
+			kinds = append(kinds, k)
+		}
+	}
+
+	if len(kinds) == 0 {
+		kinds = available
+	}
+
+	parts := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		parts = append(parts, timelineSources[k])
+	}
+	union := strings.Join(parts, "\n\t\tUNION ALL")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// 404 hanya kalau customer memang tidak ada (bukan sekadar timeline kosong)
+	var exists bool
+	done := h.trackQuery(ctx, "customerExists")
+	err := h.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM customers WHERE customer_id = $1)`, customerID,
+	).Scan(&exists)
+	done(1, err)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query customer failed", err)
+		return
+	}
+	if !exists {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "customer not found"})
+		return
+	}
+	if err := h.checkCustomerScope(ctx, customerID); err != nil {
+		if !writeCustomerAccessError(w, err) {
+			writeError(w, http.StatusInternalServerError, "check customer scope failed", err)
+		}
+		return
+	}
+
+	var total int
+	done = h.trackQuery(ctx, "countTimeline")
+	err = h.DB.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT COUNT(1) FROM (%s) AS t`, union), customerID,
+	).Scan(&total)
+	done(1, err)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "count timeline failed", err)
+		return
+	}
+
+	done = h.trackQuery(ctx, "listTimeline")
+	rows, err := h.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT kind, at, table_name, record_id, details
+		FROM (%s) AS t(kind, at, table_name, record_id, details)
+		ORDER BY at %s, kind, record_id
+		LIMIT $2 OFFSET $3
+	`, union, order), customerID, limit, offset)
+	if err != nil {
+		done(0, err)
+		writeError(w, http.StatusInternalServerError, "query timeline failed", err)
+		return
+	}
+	defer rows.Close()
+
+	events := make([]TimelineEvent, 0, limit)
+	for rows.Next() {
+		var ev TimelineEvent
+		var details []byte
+		if err := rows.Scan(&ev.Kind, &ev.At, &ev.Table, &ev.RecordID, &details); err != nil {
+			done(len(events), err)
+			writeError(w, http.StatusInternalServerError, "scan timeline failed", err)
+			return
+		}
+		ev.Details = json.RawMessage(details)
+		events = append(events, ev)
+	}
+	err = rows.Err()
+	done(len(events), err)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "iterate timeline failed", err)
+		return
+	}
+
+	if !h.recordAccess(w, r, audit.ActionTimelineView, []string{customerID}, nil) {
+		return
+	}
+	h.writeCustomerJSON(w, r, http.StatusOK, &CustomerTimelineResponse{
+		CustomerID: customerID,
+		Events:     events,
+		Kinds:      available,
+		Limit:      limit,
+		Offset:     offset,
+		Total:      total,
+	})
+}
+
+// timelineKinds mengembalikan kind yang bisa dihasilkan; profile_changed hanya kalau histori aktif.
+func (h *Handlers) timelineKinds() []string {
+	out := make([]string, 0, len(timelineKinds))
+	for _, k := range timelineKinds {
+		if k == TimelineProfileChanged && !h.HistoryEnabled {
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
@@ -196,6 +196,9 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"mini-poc-02/backend/internal/audit"
+	"mini-poc-02/backend/internal/rbac"
 )
 
 // CustomerSummary is the row shape for the list endpoint.
@@ -218,6 +221,7 @@ type ListCustomersResponse struct {
 	Limit     int               `json:"limit"`
 	Offset    int               `json:"offset"`
 	Total     int               `json:"total"`
+	AsOf      *time.Time        `json:"as_of,omitempty"`
 }
 
 // ListCustomers serves:
@@ -232,10 +236,19 @@ type ListCustomersResponse struct {
 //
 //	sort_by=last_updated|registration_date|full_name
 //	order=asc|desc
+//
+// plus point-in-time (butuh HISTORY_MODE):
+//
+//	as_of=2024-06-30 (RFC3339 atau YYYY-MM-DD = akhir hari itu)
 func (h *Handlers) ListCustomers(w http.ResponseWriter, r *http.Request) {
 	limit := queryInt(r, "limit", 20)
 	offset := queryInt(r, "offset", 0)
 
+	asOf, ok := h.readAsOf(w, r, "as_of")
+	if !ok {
+		return
+	}
+
 	if limit <= 0 {
 		limit = 20
 	}
@@ -321,16 +334,27 @@ func (h *Handlers) ListCustomers(w http.ResponseWriter, r *http.Request) {
 		where = append(where, "gender = "+p)
 	}
 
+	// batasan baris dari RBAC (mis. staf cabang hanya province/city miliknya)
+	if pred := rbac.Predicate(r.Context(), "", addArg); pred != "" {
+		where = append(where, pred)
+	}
+
 	whereSQL := ""
 	if len(where) > 0 {
 		whereSQL = "WHERE " + strings.Join(where, " AND ")
 	}
 
+	from, args, err := h.tableFrom("customers", asOf, args)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "build as_of source failed", err)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	// total count
-	total, err := h.countCustomers(ctx, whereSQL, args)
+	total, err := h.countCustomers(ctx, from, whereSQL, args)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "count customers failed", err)
 		return
@@ -352,14 +376,16 @@ SELECT
   status,
   registration_date,
   last_updated
-FROM customers
+FROM %s
 %s
 ORDER BY %s %s
 LIMIT %s OFFSET %s
-`, whereSQL, orderCol, strings.ToUpper(sortDir), limitPH, offsetPH)
+`, from, whereSQL, orderCol, strings.ToUpper(sortDir), limitPH, offsetPH)
 
+	done := h.trackQuery(ctx, "listCustomers")
 	rows, err := h.DB.QueryContext(ctx, query, args...)
 	if err != nil {
+		done(0, err)
 		writeError(w, http.StatusInternalServerError, "query customers failed", err)
 		return
 	}
@@ -380,27 +406,41 @@ LIMIT %s OFFSET %s
 			&c.RegistrationDate,
 			&c.LastUpdated,
 		); err != nil {
+			done(len(out), err)
 			writeError(w, http.StatusInternalServerError, "scan customers failed", err)
 			return
 		}
 		out = append(out, c)
 	}
-	if err := rows.Err(); err != nil {
+	err = rows.Err()
+	done(len(out), err)
+	if err != nil {
 		writeError(w, http.StatusInternalServerError, "iterate customers failed", err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, ListCustomersResponse{
+	ids := make([]string, len(out))
+	for i, c := range out {
+		ids[i] = c.CustomerID
+	}
+	if !h.recordAccess(w, r, audit.ActionCustomerList, ids, nil) {
+		return
+	}
+
+	h.writeCustomerJSON(w, r, http.StatusOK, &ListCustomersResponse{
 		Customers: out,
 		Limit:     limit,
 		Offset:    offset,
 		Total:     total,
+		AsOf:      asOf,
 	})
 }
 
-func (h *Handlers) countCustomers(ctx context.Context, whereSQL string, args []any) (int, error) {
-	q := fmt.Sprintf(`SELECT COUNT(1) FROM customers %s`, whereSQL)
-	var n int
+func (h *Handlers) countCustomers(ctx context.Context, from, whereSQL string, args []any) (n int, err error) {
+	done := h.trackQuery(ctx, "countCustomers")
+	defer func() { done(1, err) }()
+
+	q := fmt.Sprintf(`SELECT COUNT(1) FROM %s %s`, from, whereSQL)
 	if err := h.DB.QueryRowContext(ctx, q, args...).Scan(&n); err != nil {
 		return 0, err
 	}
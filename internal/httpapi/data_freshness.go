@@ -0,0 +1,393 @@
+// internal/httpapi/data_freshness.go
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"mini-poc-02/backend/internal/logging"
+)
+
+// Mode middleware freshness.
+const (
+	FreshnessModeOff     = "off"     // tanpa header/meta
+	FreshnessModeHeaders = "headers" // header X-Data-As-Of / X-Sync-Lag-Seconds saja
+	FreshnessModeFlag    = "flag"    // + X-Data-Stale: true kalau lag melewati SLA
+	FreshnessModeReject  = "reject"  // + 503 untuk endpoint data kalau lag melewati SLA
+)
+
+// DataFreshnessConfig mengatur middleware freshness.
+type DataFreshnessConfig struct {
+	Mode        string
+	CacheTTL    time.Duration // umur cache baris sync_audit terbaru
+	ErrorTTL    time.Duration // umur cache error baca sync_audit (default 1s)
+	MetaDefault bool          // sisipkan meta.freshness tanpa perlu ?meta=freshness
+}
+
+// DataFreshnessInfo adalah ringkasan umur data ODS, dari baris sync_audit terbaru.
+type DataFreshnessInfo struct {
+	AsOf             *time.Time `json:"as_of"`             // last_target_ts (fallback last_success_at)
+	LagSeconds       *int       `json:"lag_seconds"`       // lag_seconds baris terbaru
+	AuditAgeSeconds  *int       `json:"audit_age_seconds"` // umur baris terbaru (now - created_at)
+	SLATargetSeconds int        `json:"sla_target_seconds"`
+	Stale            bool       `json:"stale"`
+	StaleRule        string     `json:"stale_rule,omitempty"` // lag_warn | max_since_success | max_audit_age
+	CheckedAt        time.Time  `json:"checked_at"`
+}
+
+// EffectiveLagSeconds: lag yang dilaporkan ke klien. Kalau sync berhenti menulis
+// sync_audit, lag_seconds terakhir tetap kecil padahal data terus menua, jadi umur
+// baris terbaru dipakai kalau lebih besar.
+func (i *DataFreshnessInfo) EffectiveLagSeconds() *int {
+	switch {
+	case i.LagSeconds == nil:
+		return i.AuditAgeSeconds
+	case i.AuditAgeSeconds != nil && *i.AuditAgeSeconds > *i.LagSeconds:
+		return i.AuditAgeSeconds
+	}
+	return i.LagSeconds
+}
+
+// DataFreshness membaca sync_audit paling banyak sekali per CacheTTL, supaya header
+// di setiap response tidak menambah satu query per request.
+type DataFreshness struct {
+	cfg DataFreshnessConfig
+	db  *sql.DB
+	sla func() SLAConfig
+
+	group singleflight.Group // query sync_audit yang sedang berjalan dipakai bersama
+
+	mu      sync.Mutex
+	info    *DataFreshnessInfo
+	err     error
+	fetched time.Time
+}
+
+func NewDataFreshness(h *Handlers, cfg DataFreshnessConfig) *DataFreshness {
+	if cfg.Mode == "" {
+		cfg.Mode = FreshnessModeHeaders
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 5 * time.Second
+	}
+	if cfg.ErrorTTL <= 0 {
+		cfg.ErrorTTL = time.Second
+	}
+	return &DataFreshness{cfg: cfg, db: h.DB, sla: func() SLAConfig { return h.SLA }}
+}
+
+// Get mengembalikan info dari cache. Cache yang kedaluwarsa tetap dilayani sementara
+// query baru berjalan di background; hanya saat belum ada info (start, atau setelah error)
+// request menunggu query, dan request bersamaan berbagi satu query. Error di-cache
+// selama ErrorTTL supaya DB yang bermasalah tidak dibanjiri query dari setiap request.
+func (d *DataFreshness) Get(ctx context.Context) (*DataFreshnessInfo, error) {
+	d.mu.Lock()
+	info, err, age := d.info, d.err, time.Since(d.fetched)
+	d.mu.Unlock()
+
+	switch {
+	case err != nil && age < d.cfg.ErrorTTL:
+		return nil, err
+	case info != nil && age < d.cfg.CacheTTL:
+		return info, nil
+	case info != nil:
+		d.group.DoChan("sync_audit", d.load) // hasil diambil request berikutnya dari cache
+		return info, nil
+	}
+
+	select {
+	case res := <-d.group.DoChan("sync_audit", d.load):
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*DataFreshnessInfo), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load membaca baris sync_audit terbaru dan menyimpannya ke cache. Tidak terikat context
+// request, karena hasilnya dipakai bersama; lock hanya dipegang saat menyimpan hasil.
+func (d *DataFreshness) load() (any, error) {
+	info, err := d.query()
+
+	d.mu.Lock()
+	d.info, d.err, d.fetched = info, err, time.Now()
+	d.mu.Unlock()
+	return info, err
+}
+
+func (d *DataFreshness) query() (*DataFreshnessInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var (
+		target      string
+		createdAt   sql.NullTime
+		lastTarget  sql.NullTime
+		lastSuccess sql.NullTime
+		lag         sql.NullInt64
+	)
+	err := d.db.QueryRowContext(ctx, `
+		SELECT target_name, created_at, last_target_ts, last_success_at, lag_seconds
+		FROM sync_audit
+		ORDER BY created_at DESC
+		LIMIT 1
+	`).Scan(&target, &createdAt, &lastTarget, &lastSuccess, &lag)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return freshnessInfo(d.sla().For(target), createdAt, lastTarget, lastSuccess, lag, time.Now().UTC()), nil
+}
+
+// freshnessInfo menerapkan policy SLA tabel ke baris sync_audit terbaru: data basi kalau
+// lag melewati target, sync terakhir yang sukses terlalu lama (max_since_success), atau
+// sync_audit berhenti ditulis (max_audit_age). Tanpa baris sama sekali, umur data tidak diketahui.
+func freshnessInfo(p SLAPolicy, createdAt, lastTarget, lastSuccess sql.NullTime, lag sql.NullInt64, now time.Time) *DataFreshnessInfo {
+	info := &DataFreshnessInfo{
+		SLATargetSeconds: p.LagWarnSeconds,
+		CheckedAt:        now,
+	}
+	switch {
+	case lastTarget.Valid:
+		info.AsOf = &lastTarget.Time
+	case lastSuccess.Valid:
+		info.AsOf = &lastSuccess.Time
+	}
+	if !createdAt.Valid {
+		return info
+	}
+
+	age := int(now.Sub(createdAt.Time) / time.Second)
+	if age < 0 {
+		age = 0 // clock skew DB vs API
+	}
+	info.AuditAgeSeconds = &age
+
+	stale := func(rule string) {
+		if !info.Stale {
+			info.Stale, info.StaleRule = true, rule
+		}
+	}
+	if lag.Valid {
+		v := int(lag.Int64)
+		info.LagSeconds = &v
+		if v > p.LagWarnSeconds {
+			stale("lag_warn")
+		}
+	}
+	if p.MaxSinceSuccessSeconds > 0 &&
+		(!lastSuccess.Valid || now.Sub(lastSuccess.Time) > time.Duration(p.MaxSinceSuccessSeconds)*time.Second) {
+		stale("max_since_success")
+	}
+	if p.MaxAuditAgeSeconds > 0 && age > p.MaxAuditAgeSeconds {
+		stale("max_audit_age")
+	}
+	return info
+}
+
+// Middleware menambahkan header freshness ke setiap response, dan (opsional) meta.freshness
+// ke body JSON kalau diminta lewat ?meta=freshness atau MetaDefault.
+func (d *DataFreshness) Middleware(next http.Handler) http.Handler {
+	if d == nil || d.cfg.Mode == FreshnessModeOff {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, err := d.Get(r.Context())
+		if err != nil {
+			// fail-open: data tetap dilayani, tapi klien tahu umur data tidak diketahui
+			logging.FromContext(r.Context()).Warn("data freshness: read sync_audit failed", "error", err)
+			w.Header().Set("X-Data-Freshness", "unknown")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if info.AsOf != nil {
+			w.Header().Set("X-Data-As-Of", info.AsOf.UTC().Format(time.RFC3339))
+		}
+		if lag := info.EffectiveLagSeconds(); lag != nil {
+			w.Header().Set("X-Sync-Lag-Seconds", strconv.Itoa(*lag))
+		}
+		if info.Stale && d.cfg.Mode != FreshnessModeHeaders {
+			w.Header().Set("X-Data-Stale", "true")
+		}
+
+		if info.Stale && d.cfg.Mode == FreshnessModeReject && servesODSData(r.URL.Path) {
+			w.Header().Set("Retry-After", strconv.Itoa(info.SLATargetSeconds))
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+				"error": "ODS data is stale: sync exceeds SLA (" + info.StaleRule + ")",
+				"meta":  map[string]any{"freshness": info},
+			})
+			return
+		}
+
+		if !d.wantsMeta(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		buf.flushWithMeta(info)
+	})
+}
+
+// streamRoutes: route SSE di NewRouter; body-nya tidak boleh di-buffer untuk meta.
+// Middleware ini berjalan sebelum routing, jadi pola dicocokkan sendiri per segmen path.
+var streamRoutes = []string{
+	"/api/v1/stream/dashboard",
+	"/api/v1/customers/{customer_id}/events",
+}
+
+func isStreamRoute(path string) bool {
+	for _, pattern := range streamRoutes {
+		if matchRoute(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute mencocokkan path dengan pola chi sederhana: segmen {param} cocok dengan
+// segmen apa pun yang tidak kosong.
+func matchRoute(pattern, path string) bool {
+	ps, xs := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(ps) != len(xs) {
+		return false
+	}
+	for i, p := range ps {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if xs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != xs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *DataFreshness) wantsMeta(r *http.Request) bool {
+	if isStreamRoute(r.URL.Path) {
+		return false
+	}
+	for _, v := range strings.Split(r.URL.Query().Get("meta"), ",") {
+		if strings.TrimSpace(v) == "freshness" {
+			return true
+		}
+	}
+	return d.cfg.MetaDefault
+}
+
+// servesODSData: endpoint yang membaca data ODS. Health & endpoint sync tetap dilayani
+// saat lag tinggi, karena justru dipakai untuk mendiagnosis masalah sync.
+func servesODSData(path string) bool {
+	switch {
+	case path == "/api/v1/health",
+		strings.HasPrefix(path, "/api/v1/sync/"),
+		strings.HasPrefix(path, "/api/v1/stream/"):
+		return false
+	}
+	return strings.HasPrefix(path, "/api/v1/")
+}
+
+// bufferedResponse menahan body supaya meta bisa disisipkan sebelum dikirim.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	passthrough bool // handler sudah Flush: body diteruskan langsung, tanpa meta
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.passthrough {
+		return
+	}
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedResponse) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.passthrough {
+		return b.ResponseWriter.Write(p)
+	}
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// Flush dari handler berarti response di-stream: meta tidak bisa disisipkan lagi, jadi
+// body yang sudah tertahan dikirim dan sisa response diteruskan tanpa buffer.
+func (b *bufferedResponse) Flush() {
+	if !b.passthrough {
+		b.passthrough = true
+		b.ResponseWriter.WriteHeader(b.status)
+		_, _ = b.ResponseWriter.Write(b.body.Bytes())
+		b.body.Reset()
+	}
+	_ = http.NewResponseController(b.ResponseWriter).Flush()
+}
+
+func (b *bufferedResponse) flushWithMeta(info *DataFreshnessInfo) {
+	if b.passthrough {
+		return
+	}
+	body := b.body.Bytes()
+	if strings.HasPrefix(b.Header().Get("Content-Type"), "application/json") {
+		if merged, ok := withFreshnessMeta(body, info); ok {
+			body = merged
+			b.Header().Del("Content-Length")
+		}
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	_, _ = b.ResponseWriter.Write(body)
+}
+
+// withFreshnessMeta menyisipkan "meta": {"freshness": ...} di awal objek JSON tanpa
+// mengubah urutan field lain. Body yang bukan objek, atau sudah punya "meta", dibiarkan.
+func withFreshnessMeta(body []byte, info *DataFreshnessInfo) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return nil, false
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return nil, false
+	}
+	if _, exists := probe["meta"]; exists {
+		return nil, false
+	}
+
+	meta, err := json.Marshal(map[string]any{"freshness": info})
+	if err != nil {
+		return nil, false
+	}
+
+	var out bytes.Buffer
+	out.WriteString(`{"meta":`)
+	out.Write(meta)
+	if len(probe) > 0 {
+		out.WriteByte(',')
+	}
+	out.Write(trimmed[1:])
+	out.WriteByte('\n')
+	return out.Bytes(), true
+}
@@ -0,0 +1,93 @@
+// internal/httpapi/data_freshness_test.go
+package httpapi
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFreshnessInfo(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(-d), Valid: true} }
+	lag := func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} }
+	policy := SLAPolicy{LagWarnSeconds: 60, MaxSinceSuccessSeconds: 600, MaxAuditAgeSeconds: 300}
+
+	tests := []struct {
+		name        string
+		policy      SLAPolicy
+		createdAt   sql.NullTime
+		lastSuccess sql.NullTime
+		lag         sql.NullInt64
+		wantStale   string // "" = tidak basi, selain itu stale_rule
+		wantLag     *int   // EffectiveLagSeconds
+	}{
+		{name: "no sync_audit row", policy: policy},
+		{name: "fresh", policy: policy, createdAt: ago(10 * time.Second), lastSuccess: ago(10 * time.Second), lag: lag(5), wantLag: intp(10)},
+		{name: "lag over target", policy: policy, createdAt: ago(time.Second), lastSuccess: ago(time.Second), lag: lag(61), wantStale: "lag_warn", wantLag: intp(61)},
+		{
+			name: "audit rows stopped with small lag", policy: policy,
+			createdAt: ago(301 * time.Second), lastSuccess: ago(301 * time.Second), lag: lag(2),
+			wantStale: "max_audit_age", wantLag: intp(301),
+		},
+		{
+			name: "last success too old", policy: policy,
+			createdAt: ago(10 * time.Second), lastSuccess: ago(601 * time.Second), lag: lag(2),
+			wantStale: "max_since_success", wantLag: intp(10),
+		},
+		{name: "never succeeded", policy: policy, createdAt: ago(10 * time.Second), lag: lag(2), wantStale: "max_since_success", wantLag: intp(10)},
+		{
+			name: "rules disabled", policy: SLAPolicy{LagWarnSeconds: 60},
+			createdAt: ago(24 * time.Hour), lag: lag(2),
+			wantLag: intp(86400),
+		},
+		{name: "NULL lag uses audit age", policy: policy, createdAt: ago(30 * time.Second), lastSuccess: ago(30 * time.Second), wantLag: intp(30)},
+		{name: "row from the future", policy: policy, createdAt: ago(-5 * time.Second), lastSuccess: ago(0), lag: lag(3), wantLag: intp(3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := freshnessInfo(tt.policy, tt.createdAt, sql.NullTime{}, tt.lastSuccess, tt.lag, now)
+			if info.Stale != (tt.wantStale != "") || info.StaleRule != tt.wantStale {
+				t.Errorf("stale = %v (%q), want rule %q", info.Stale, info.StaleRule, tt.wantStale)
+			}
+			got := info.EffectiveLagSeconds()
+			if (got == nil) != (tt.wantLag == nil) || (got != nil && *got != *tt.wantLag) {
+				t.Errorf("effective lag = %v, want %v", ptrString(got), ptrString(tt.wantLag))
+			}
+		})
+	}
+}
+
+func TestDataFreshnessHeaderUsesAuditAge(t *testing.T) {
+	createdAt := time.Now().Add(-10 * time.Minute)
+	db, _ := newFakeDB(t, func(string, []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"target_name", "created_at", "last_target_ts", "last_success_at", "lag_seconds"}, [][]driver.Value{
+			{"ods_crm", createdAt, createdAt, createdAt, int64(3)},
+		}, nil
+	})
+	h := &Handlers{DB: db}
+	h.SLA.Default = SLAPolicy{LagWarnSeconds: 60, MaxAuditAgeSeconds: 300}
+	d := NewDataFreshness(h, DataFreshnessConfig{Mode: FreshnessModeReject})
+
+	rec := httptest.NewRecorder()
+	d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("stale ODS request reached handler")
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/customers", nil))
+
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("X-Data-Stale") != "true" {
+		t.Fatalf("status = %d, headers %v", rec.Code, rec.Header())
+	}
+	if got := rec.Header().Get("X-Sync-Lag-Seconds"); got != "600" && got != "599" && got != "601" {
+		t.Errorf("X-Sync-Lag-Seconds = %q, want audit age ~600", got)
+	}
+}
+
+func ptrString(p *int) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
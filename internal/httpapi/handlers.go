@@ -76,18 +76,121 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
+
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/changefeed"
+	"mini-poc-02/backend/internal/cors"
+	"mini-poc-02/backend/internal/heartbeat"
+	"mini-poc-02/backend/internal/logging"
+	"mini-poc-02/backend/internal/metrics"
+	"mini-poc-02/backend/internal/notify"
+	"mini-poc-02/backend/internal/pii"
+	"mini-poc-02/backend/internal/ratelimit"
+	"mini-poc-02/backend/internal/rbac"
+	"mini-poc-02/backend/internal/realip"
+	"mini-poc-02/backend/internal/reconcile"
+	"mini-poc-02/backend/internal/tracing"
 )
 
 type Handlers struct {
 	DB *sql.DB
+
+	// KPIAnomalies opsional: kalau nil, deteksi anomali row count dimatikan.
+	KPIAnomalies *KPIAnomalyDetector
+
+	// SLA policy (default + override per tabel) untuk /sync/health dan /sync/history
+	SLA SLAConfig
+
+	// Connectors opsional: probe Kafka Connect untuk /sync/health
+	Connectors *ConnectorProbe
+
+	// Reconciler opsional: rekonsiliasi MySQL source vs ODS (butuh SOURCE_DB_*)
+	Reconciler *reconcile.Reconciler
+
+	// Heartbeat opsional: pengukuran latency CDC end-to-end oleh backend sendiri
+	Heartbeat *heartbeat.Worker
+
+	// Dashboard: hub SSE untuk /stream/dashboard
+	Dashboard *DashboardHub
+
+	// ChangeFeed opsional: fan-out NOTIFY perubahan per customer (SSE)
+	ChangeFeed *changefeed.Broker
+
+	// HistoryEnabled: tabel histori perubahan tersedia (HISTORY_MODE), syarat untuk as_of
+	HistoryEnabled bool
+	// HistoryAsOf: nilai lama di histori berformat Postgres (HISTORY_MODE=trigger), syarat untuk
+	// rekonstruksi as_of/diff. Mode debezium menyimpan encoding converter Debezium apa adanya.
+	HistoryAsOf bool
+
+	// Notifier opsional: webhook saat status sync berubah
+	Notifier *notify.Notifier
+
+	// DataFreshness opsional: header X-Data-As-Of / X-Sync-Lag-Seconds di semua response
+	DataFreshness *DataFreshness
+
+	// Auth opsional: verifikasi bearer JWT (nil = semua route publik)
+	Auth *auth.Verifier
+
+	// RBAC opsional: permission per route + batasan baris customer (butuh Auth)
+	RBAC *rbac.Enforcer
+
+	// PII opsional: masking NIK/telepon/email/alamat per role (nil = data ditampilkan utuh)
+	PII *pii.Masker
+
+	// Audit opsional: log akses data customer (append-only) + GET /audit
+	Audit *AccessAudit
+
+	// APIKeys opsional: admin API key service-to-service (verifikasi X-API-Key ada di Auth)
+	APIKeys *APIKeyAdmin
+
+	// RateLimit opsional: token bucket per klien + batas concurrency per route
+	RateLimit *ratelimit.Limiter
+
+	// RealIP opsional: IP klien dari X-Forwarded-For, hanya dari proxy terpercaya (nil = IP peer socket)
+	RealIP *realip.Resolver
+
+	// CORS opsional: untuk frontend di origin lain (nil = tanpa header CORS)
+	CORS *cors.CORS
+
+	// Logger untuk access log & log handler (nil = slog.Default()); AccessLog mematikan/menyalakan
+	// baris per request (error 5xx tetap dicatat)
+	Logger    *slog.Logger
+	AccessLog bool
+
+	// Metrics opsional: GET /metrics (Prometheus) + durasi query bernama (nil = dimatikan)
+	Metrics       *metrics.Metrics
+	MetricsPublic bool // /metrics tanpa auth & RBAC
+
+	// Tracer opsional: span server per request + child span per query bernama (nil = dimatikan)
+	Tracer *tracing.Tracer
+
+	// BaseContext: context proses (dibatalkan saat shutdown) untuk pekerjaan latar yang dipicu
+	// request, mis. run rekonsiliasi manual. nil = context.Background().
+	BaseContext context.Context
 }
 
 func NewHandlers(db *sql.DB) *Handlers {
-	return &Handlers{DB: db}
+	return &Handlers{DB: db, AccessLog: true}
+}
+
+func (h *Handlers) baseContext() context.Context {
+	if h.BaseContext != nil {
+		return h.BaseContext
+	}
+	return context.Background()
+}
+
+func (h *Handlers) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -102,6 +205,10 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 }
 
 func writeError(w http.ResponseWriter, status int, message string, err error) {
+	if status >= http.StatusInternalServerError && err != nil {
+		// tercatat di baris access log request ini (dengan request_id)
+		logging.RecordError(w, message, err)
+	}
 	payload := map[string]any{
 		"error": message,
 	}
@@ -111,6 +218,25 @@ func writeError(w http.ResponseWriter, status int, message string, err error) {
 	writeJSON(w, status, payload)
 }
 
+// queryError membawa pesan error yang ditampilkan ke client bersama error asli dari DB.
+type queryError struct {
+	Message string
+	Err     error
+}
+
+func (e *queryError) Error() string { return e.Message + ": " + e.Err.Error() }
+func (e *queryError) Unwrap() error { return e.Err }
+
+// writeQueryError menulis 500 dengan pesan dari queryError (atau pesan generik).
+func writeQueryError(w http.ResponseWriter, err error) {
+	var qe *queryError
+	if errors.As(err, &qe) {
+		writeError(w, http.StatusInternalServerError, qe.Message, qe.Err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "query failed", err)
+}
+
 func parseIntDefault(s string, def int) int {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -0,0 +1,187 @@
+// internal/httpapi/kpi_anomaly.go
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// KPIAnomalyConfig mengatur sensitivitas deteksi anomali row count.
+type KPIAnomalyConfig struct {
+	Window         int           // jumlah sample yang disimpan per tabel
+	MinSamples     int           // minimal delta historis sebelum z-score dipakai
+	ZScore         float64       // |z| > ZScore => anomali (0 = nonaktif)
+	PctChange      float64       // |perubahan %| vs sample terakhir > PctChange => anomali (0 = nonaktif)
+	SampleInterval time.Duration // jarak minimal antar sample yang disimpan
+}
+
+// KPIAnomaly menjelaskan perubahan row count yang tidak wajar.
+// Biasanya gejala pertama pipeline CDC bermasalah (sink berhenti, truncate, replay ganda).
+type KPIAnomaly struct {
+	Table         string    `json:"table"`
+	PreviousCount int       `json:"previous_count"`
+	CurrentCount  int       `json:"current_count"`
+	Delta         int       `json:"delta"`
+	ChangePct     *float64  `json:"change_pct,omitempty"`
+	ZScore        *float64  `json:"z_score,omitempty"`
+	Rule          string    `json:"rule"` // pct_change | z_score
+	Message       string    `json:"message"`
+	PreviousAt    time.Time `json:"previous_at"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+type countSample struct {
+	At    time.Time
+	Count int
+}
+
+// KPIAnomalyDetector menyimpan rolling history row count per tabel (in-memory)
+// dan menilai apakah count terbaru menyimpang dari pola sebelumnya.
+type KPIAnomalyDetector struct {
+	cfg KPIAnomalyConfig
+
+	mu      sync.Mutex
+	samples map[string][]countSample
+}
+
+func NewKPIAnomalyDetector(cfg KPIAnomalyConfig) *KPIAnomalyDetector {
+	if cfg.Window < 2 {
+		cfg.Window = 2
+	}
+	if cfg.MinSamples < 2 {
+		cfg.MinSamples = 2
+	}
+	return &KPIAnomalyDetector{
+		cfg:     cfg,
+		samples: map[string][]countSample{},
+	}
+}
+
+// Observe menilai count terbaru terhadap history lalu (kalau SampleInterval sudah lewat)
+// menyimpannya sebagai sample baru. Return nil kalau tidak ada anomali.
+func (d *KPIAnomalyDetector) Observe(table string, count int, now time.Time) *KPIAnomaly {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hist := d.samples[table]
+	if len(hist) == 0 || now.Sub(hist[len(hist)-1].At) >= d.cfg.SampleInterval {
+		anomaly := d.evaluate(table, hist, count, now)
+		hist = append(hist, countSample{At: now, Count: count})
+		if len(hist) > d.cfg.Window {
+			hist = hist[len(hist)-d.cfg.Window:]
+		}
+		d.samples[table] = hist
+		return anomaly
+	}
+
+	// Masih di interval yang sama: nilai terhadap history sebelum sample interval ini,
+	// supaya anomali tetap dilaporkan selama count masih menyimpang dan hilang begitu pulih.
+	return d.evaluate(table, hist[:len(hist)-1], count, now)
+}
+
+func (d *KPIAnomalyDetector) evaluate(table string, hist []countSample, count int, now time.Time) *KPIAnomaly {
+	if len(hist) == 0 {
+		return nil
+	}
+
+	last := hist[len(hist)-1]
+	a := &KPIAnomaly{
+		Table:         table,
+		PreviousCount: last.Count,
+		CurrentCount:  count,
+		Delta:         count - last.Count,
+		PreviousAt:    last.At,
+		DetectedAt:    now,
+	}
+
+	if last.Count > 0 {
+		pct := float64(a.Delta) / float64(last.Count) * 100
+		a.ChangePct = &pct
+	}
+
+	// z-score dihitung atas delta antar sample (bukan level count), karena count
+	// normalnya naik terus sehingga level-nya sendiri tidak stasioner.
+	if len(hist)-1 >= d.cfg.MinSamples {
+		deltas := make([]float64, 0, len(hist)-1)
+		for i := 1; i < len(hist); i++ {
+			deltas = append(deltas, float64(hist[i].Count-hist[i-1].Count))
+		}
+		mean, std := meanStd(deltas)
+		if std > 0 {
+			z := (float64(a.Delta) - mean) / std
+			a.ZScore = &z
+		}
+	}
+
+	switch {
+	case d.cfg.PctChange > 0 && a.ChangePct != nil && math.Abs(*a.ChangePct) > d.cfg.PctChange:
+		a.Rule = "pct_change"
+		a.Message = fmt.Sprintf(
+			"%s row count changed %+d (%+.1f%%) since %s, above the %.1f%% threshold",
+			table, a.Delta, *a.ChangePct, last.At.UTC().Format(time.RFC3339), d.cfg.PctChange,
+		)
+	case d.cfg.PctChange > 0 && last.Count == 0 && count > 0:
+		a.Rule = "pct_change"
+		a.Message = fmt.Sprintf("%s row count jumped from 0 to %d", table, count)
+	case d.cfg.ZScore > 0 && a.ZScore != nil && math.Abs(*a.ZScore) > d.cfg.ZScore:
+		a.Rule = "z_score"
+		a.Message = fmt.Sprintf(
+			"%s row count changed %+d since %s (z-score %.2f over the last %d samples, threshold %.1f)",
+			table, a.Delta, last.At.UTC().Format(time.RFC3339), *a.ZScore, len(hist), d.cfg.ZScore,
+		)
+	default:
+		return nil
+	}
+	return a
+}
+
+func meanStd(xs []float64) (mean, std float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	for _, x := range xs {
+		std += (x - mean) * (x - mean)
+	}
+	std = math.Sqrt(std / float64(len(xs)))
+	return mean, std
+}
+
+// checkKPIAnomalies menghitung ulang row count tabel yang dipantau dan menilainya.
+// Dipakai oleh /sync/health supaya warning tetap muncul walau /stats/kpi tidak dipanggil.
+func (h *Handlers) checkKPIAnomalies(ctx context.Context) ([]KPIAnomaly, error) {
+	if h.KPIAnomalies == nil {
+		return nil, nil
+	}
+
+	var customers, apps int
+	if err := h.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers`).Scan(&customers); err != nil {
+		return nil, err
+	}
+	if err := h.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM credit_applications`).Scan(&apps); err != nil {
+		return nil, err
+	}
+	return h.observeKPICounts(customers, apps), nil
+}
+
+func (h *Handlers) observeKPICounts(customers, creditApplications int) []KPIAnomaly {
+	now := time.Now()
+	out := make([]KPIAnomaly, 0, 2)
+	if a := h.KPIAnomalies.Observe("customers", customers, now); a != nil {
+		out = append(out, *a)
+	}
+	if a := h.KPIAnomalies.Observe("credit_applications", creditApplications, now); a != nil {
+		out = append(out, *a)
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+// internal/httpapi/kpi_anomaly_test.go
+package httpapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKPIAnomalyDetectorObserve(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	type obs struct {
+		after    time.Duration // dari t0
+		count    int
+		wantRule string // "" = tidak ada anomali
+	}
+
+	tests := []struct {
+		name string
+		cfg  KPIAnomalyConfig
+		obs  []obs
+	}{
+		{
+			name: "first sample is never an anomaly",
+			cfg:  KPIAnomalyConfig{PctChange: 20, ZScore: 3, SampleInterval: time.Minute},
+			obs:  []obs{{0, 100, ""}, {time.Second, 1000, ""}},
+		},
+		{
+			name: "pct change threshold",
+			cfg:  KPIAnomalyConfig{PctChange: 20, SampleInterval: time.Minute},
+			obs: []obs{
+				{0, 100, ""},
+				{time.Minute, 120, ""}, // tepat 20%: belum melewati threshold
+				{2 * time.Minute, 150, "pct_change"},
+				{3 * time.Minute, 90, "pct_change"},
+			},
+		},
+		{
+			name: "jump from empty table",
+			cfg:  KPIAnomalyConfig{PctChange: 20, SampleInterval: time.Minute},
+			obs:  []obs{{0, 0, ""}, {time.Minute, 5, "pct_change"}},
+		},
+		{
+			name: "z-score after min samples",
+			cfg:  KPIAnomalyConfig{Window: 10, ZScore: 3, MinSamples: 3, SampleInterval: time.Minute},
+			obs: []obs{
+				{0, 1000, ""},
+				{1 * time.Minute, 1010, ""},
+				{2 * time.Minute, 1020, ""},
+				{3 * time.Minute, 1031, ""}, // baru 2 delta historis: z-score belum dipakai
+				{4 * time.Minute, 1040, ""},
+				{5 * time.Minute, 1200, "z_score"},
+			},
+		},
+		{
+			name: "anomaly reported within interval while it persists",
+			cfg:  KPIAnomalyConfig{PctChange: 20, SampleInterval: time.Minute},
+			obs: []obs{
+				{0, 100, ""},
+				{time.Minute, 50, "pct_change"},
+				{time.Minute + 10*time.Second, 50, "pct_change"},
+				{time.Minute + 20*time.Second, 52, "pct_change"},
+			},
+		},
+		{
+			name: "recovery within interval clears anomaly",
+			cfg:  KPIAnomalyConfig{PctChange: 20, SampleInterval: time.Minute},
+			obs: []obs{
+				{0, 100, ""},
+				{time.Minute, 50, "pct_change"},
+				{time.Minute + 10*time.Second, 100, ""},
+				{time.Minute + 20*time.Second, 100, ""},
+			},
+		},
+		{
+			name: "recovery on next sample is compared with the anomalous sample",
+			cfg:  KPIAnomalyConfig{PctChange: 20, SampleInterval: time.Minute},
+			obs: []obs{
+				{0, 100, ""},
+				{time.Minute, 50, "pct_change"},
+				{2 * time.Minute, 50, ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewKPIAnomalyDetector(tt.cfg)
+			for i, o := range tt.obs {
+				a := d.Observe("customers", o.count, t0.Add(o.after))
+				got := ""
+				if a != nil {
+					got = a.Rule
+				}
+				if got != o.wantRule {
+					t.Fatalf("observation %d (count %d): rule = %q, want %q (%+v)", i, o.count, got, o.wantRule, a)
+				}
+			}
+		})
+	}
+}
+
+func TestKPIAnomalyFields(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewKPIAnomalyDetector(KPIAnomalyConfig{PctChange: 20, SampleInterval: time.Minute})
+	d.Observe("credit_applications", 200, t0)
+	a := d.Observe("credit_applications", 100, t0.Add(time.Minute))
+	if a == nil {
+		t.Fatal("expected anomaly")
+	}
+	if a.PreviousCount != 200 || a.CurrentCount != 100 || a.Delta != -100 || !a.PreviousAt.Equal(t0) {
+		t.Errorf("anomaly = %+v", a)
+	}
+	if a.ChangePct == nil || *a.ChangePct != -50 {
+		t.Errorf("change_pct = %v, want -50", a.ChangePct)
+	}
+
+	var nilDetector *KPIAnomalyDetector
+	if got := nilDetector.Observe("customers", 1, t0); got != nil {
+		t.Errorf("nil detector = %+v", got)
+	}
+}
@@ -0,0 +1,89 @@
+// internal/httpapi/metrics.go
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GetMetrics serves:
+//
+//	GET /metrics
+//
+// dalam text format Prometheus.
+func (h *Handlers) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.Metrics == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "metrics are disabled (set METRICS_ENABLED)"})
+		return
+	}
+	h.Metrics.Handler().ServeHTTP(w, r)
+}
+
+var syncLagDesc = prometheus.NewDesc(
+	"sync_lag_seconds",
+	"Replication lag reported by the latest sync_audit row of each target.",
+	[]string{"target_name"}, nil,
+)
+
+// syncLagCollector membaca lag_seconds baris sync_audit terbaru per target saat scrape
+// (gauge sync_lag_seconds{target_name}). Target tanpa lag (NULL) tidak punya sampel.
+type syncLagCollector struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// SyncLagCollector: collector sync_lag_seconds untuk didaftarkan ke Metrics. Set Logger
+// sebelum memanggil ini supaya error scrape tercatat di logger aplikasi.
+func (h *Handlers) SyncLagCollector() prometheus.Collector {
+	return &syncLagCollector{db: h.DB, logger: h.logger().With("component", "metrics")}
+}
+
+func (c *syncLagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- syncLagDesc
+}
+
+// Collect mengirim invalid metric kalau sync_audit gagal dibaca, supaya kegagalan tercatat
+// di promhttp_metric_handler_errors_total alih-alih gauge diam-diam hilang. Metric lain
+// tetap dikirim (lihat metrics.Handler).
+func (c *syncLagCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	fail := func(err error) {
+		c.logger.Warn("metrics: read sync_audit failed", "error", err)
+		ch <- prometheus.NewInvalidMetric(syncLagDesc, err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (target_name) target_name, lag_seconds
+		FROM sync_audit
+		ORDER BY target_name, created_at DESC
+	`)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			target string
+			lag    sql.NullInt64
+		)
+		if err := rows.Scan(&target, &lag); err != nil {
+			fail(err)
+			return
+		}
+		if lag.Valid {
+			ch <- prometheus.MustNewConstMetric(syncLagDesc, prometheus.GaugeValue, float64(lag.Int64), target)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		fail(err)
+	}
+}
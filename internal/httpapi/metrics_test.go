@@ -0,0 +1,75 @@
+// internal/httpapi/metrics_test.go
+package httpapi
+
+import (
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSyncLagCollectorEmitsOneSamplePerTarget(t *testing.T) {
+	db, fake := newFakeDB(t, func(string, []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		// baris terbaru per target (hasil DISTINCT ON); lag NULL = tanpa sampel
+		return []string{"target_name", "lag_seconds"}, [][]driver.Value{
+			{"ods_crm", int64(42)},
+			{"ods_leasing", nil},
+			{"ods_vehicle", int64(3)},
+		}, nil
+	})
+	c := (&Handlers{DB: db}).SyncLagCollector()
+
+	want := `
+# HELP sync_lag_seconds Replication lag reported by the latest sync_audit row of each target.
+# TYPE sync_lag_seconds gauge
+sync_lag_seconds{target_name="ods_crm"} 42
+sync_lag_seconds{target_name="ods_vehicle"} 3
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	q := fake.Queries()
+	if len(q) == 0 || !strings.Contains(q[0], "DISTINCT ON (target_name)") {
+		t.Errorf("query = %q, want latest row per target", q)
+	}
+}
+
+func TestSyncLagCollectorReadError(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  fakeQuery
+		wantErr string
+	}{
+		{
+			name: "query error",
+			answer: func(string, []driver.NamedValue) ([]string, [][]driver.Value, error) {
+				return nil, nil, errors.New("relation \"sync_audit\" does not exist")
+			},
+			wantErr: "sync_audit\" does not exist",
+		},
+		{
+			name: "scan error",
+			answer: func(string, []driver.NamedValue) ([]string, [][]driver.Value, error) {
+				return []string{"target_name", "lag_seconds"}, [][]driver.Value{{"ods_crm", "not a number"}}, nil
+			},
+			wantErr: "lag_seconds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t, tt.answer)
+			reg := prometheus.NewPedanticRegistry()
+			reg.MustRegister((&Handlers{DB: db, Logger: slog.New(slog.DiscardHandler)}).SyncLagCollector())
+
+			_, err := reg.Gather()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("gather err = %v, want scrape failure containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// internal/httpapi/query_trace.go
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// trackQuery mengukur satu query bernama: durasi ke db_query_duration_seconds{query=name}
+// dan, kalau request sedang di-trace, child span SQL berisi nama statement & jumlah baris.
+// done dipanggil sekali setelah hasil selesai dibaca; rows < 0 = jumlah baris tidak dicatat
+// (mis. buildKPI yang menggabungkan beberapa query).
+//
+//	done := h.trackQuery(ctx, "getCustomer")
+//	defer func() { done(1, err) }()
+func (h *Handlers) trackQuery(ctx context.Context, name string) (done func(rows int, err error)) {
+	start := time.Now()
+
+	// hanya sebagai child: query dari loop latar (mis. KPI stream) tidak membuka trace baru
+	var span trace.Span = noop.Span{}
+	if trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		_, span = h.Tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", "postgresql"),
+				attribute.String("db.operation.name", name),
+			),
+		)
+	}
+
+	return func(rows int, err error) {
+		h.Metrics.ObserveQuery(name, time.Since(start), err)
+
+		if err != nil {
+			rows = 0
+			if !errors.Is(err, sql.ErrNoRows) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
+		if rows >= 0 {
+			span.SetAttributes(attribute.Int("db.response.returned_rows", rows))
+		}
+		span.End()
+	}
+}
@@ -68,6 +68,9 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"mini-poc-02/backend/internal/logging"
+	"mini-poc-02/backend/internal/rbac"
 )
 
 func NewRouter(h *Handlers) http.Handler {
@@ -75,19 +78,64 @@ func NewRouter(h *Handlers) http.Handler {
 
 	// Basic middleware (aman untuk PoC, production-like)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	// IP klien dari X-Forwarded-For hanya kalau peer adalah proxy terpercaya (TRUSTED_PROXIES)
+	r.Use(h.RealIP.Middleware)
+	// Span server per request (melanjutkan traceparent masuk). Sesudah RequestID & RealIP karena span
+	// mencatat request_id dan IP klien; sebelum middleware lain supaya access log, auth, dsb. ikut terukur
+	r.Use(h.Tracer.Middleware)
+	// Access log + X-Request-ID di response; di luar Recoverer supaya panic tercatat sebagai 500
+	r.Use(logging.AccessLog(h.logger(), h.AccessLog))
+	r.Use(h.Metrics.Middleware)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(15 * time.Second))
-
-	// Routes
-	r.Get("/api/v1/health", h.Health)
-
-	r.Get("/api/v1/customers", h.ListCustomers)
-	// Ini akan membuat chi.URLParam(r, "customer_id") bekerja (di customer_profile_360.go)
-	r.Get("/api/v1/customers/{customer_id}/profile", h.GetCustomerProfile)
+	// CORS sebelum auth & routing: preflight OPTIONS dijawab di sini untuk semua route
+	r.Use(h.CORS.Middleware)
+	// Autentikasi JWT / X-API-Key (kalau AUTH_ISSUER di-set); /api/v1/health tetap publik
+	r.Use(h.Auth.Middleware)
+	r.Use(h.DataFreshness.Middleware)
+
+	// Otorisasi per route: r.With(h.RBAC.Require(<permission>)); nil RBAC = semua boleh
+
+	// Routes request/response biasa: dibatasi timeout
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(15 * time.Second))
+		// Rate limit setelah routing supaya rule per pola route bisa dipakai
+		r.Use(h.RateLimit.Middleware)
+
+		r.Get("/api/v1/health", h.Health)
+
+		r.With(h.RBAC.Require(rbac.PermCustomersList)).Get("/api/v1/customers", h.ListCustomers)
+		// Ini akan membuat chi.URLParam(r, "customer_id") bekerja (di customer_profile_360.go)
+		r.With(h.RBAC.Require(rbac.PermCustomersProfile)).Get("/api/v1/customers/{customer_id}/profile", h.GetCustomerProfile)
+		r.With(h.RBAC.Require(rbac.PermCustomersProfile)).Get("/api/v1/customers/{customer_id}/profile/diff", h.GetCustomerProfileDiff)
+		r.With(h.RBAC.Require(rbac.PermCustomersProfile)).Get("/api/v1/customers/{customer_id}/history", h.GetCustomerHistory)
+		r.With(h.RBAC.Require(rbac.PermCustomersProfile)).Get("/api/v1/customers/{customer_id}/timeline", h.GetCustomerTimeline)
+		r.With(h.RBAC.Require(rbac.PermCustomersReveal)).Post("/api/v1/customers/{customer_id}/reveal", h.RevealCustomerPII)
+
+		r.With(h.RBAC.Require(rbac.PermAuditRead)).Get("/api/v1/audit", h.GetAudit)
+
+		r.With(h.RBAC.Require(rbac.PermAPIKeysManage)).Post("/api/v1/admin/api-keys", h.CreateAPIKey)
+		r.With(h.RBAC.Require(rbac.PermAPIKeysManage)).Get("/api/v1/admin/api-keys", h.ListAPIKeys)
+		r.With(h.RBAC.Require(rbac.PermAPIKeysManage)).Delete("/api/v1/admin/api-keys/{key_id}", h.RevokeAPIKey)
+
+		r.With(h.RBAC.Require(rbac.PermStatsRead)).Get("/api/v1/stats/kpi", h.GetKPI)
+		r.With(h.RBAC.Require(rbac.PermSyncRead)).Get("/api/v1/sync/health", h.GetSyncHealth)
+		r.With(h.RBAC.Require(rbac.PermSyncRead)).Get("/api/v1/sync/history", h.GetSyncHistory)
+		r.With(h.RBAC.Require(rbac.PermSyncRead)).Get("/api/v1/sync/notifications", h.GetSyncNotifications)
+		r.With(h.RBAC.Require(rbac.PermSyncRead)).Get("/api/v1/sync/reconciliation", h.GetReconciliation)
+		r.With(h.RBAC.Require(rbac.PermSyncWrite)).Post("/api/v1/sync/reconciliation/run", h.RunReconciliation)
+	})
 
-	r.Get("/api/v1/stats/kpi", h.GetKPI)
-	r.Get("/api/v1/sync/health", h.GetSyncHealth)
+	// Stream long-lived (SSE): tidak boleh kena middleware.Timeout, dan tidak memakai slot
+	// concurrency default (lihat ratelimit.Limiter.StreamMiddleware)
+	r.With(h.RateLimit.StreamMiddleware, h.RBAC.Require(rbac.PermStatsRead)).Get("/api/v1/stream/dashboard", h.StreamDashboard)
+	r.With(h.RateLimit.StreamMiddleware, h.RBAC.Require(rbac.PermCustomersProfile)).Get("/api/v1/customers/{customer_id}/events", h.StreamCustomerEvents)
+
+	// Scrape Prometheus (tanpa rate limit & timeout; collector punya timeout sendiri)
+	if h.MetricsPublic {
+		r.Get("/metrics", h.GetMetrics)
+	} else {
+		r.With(h.RBAC.Require(rbac.PermMetricsRead)).Get("/metrics", h.GetMetrics)
+	}
 
 	// Optional: 404 handler custom (kalau mau)
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
@@ -102,25 +102,39 @@ type KPIResponse struct {
 	VehicleOwnership struct {
 		Total int `json:"total"`
 	} `json:"vehicle_ownership"`
+
+	// Perubahan count yang tidak wajar (indikasi sync CDC bermasalah)
+	Warnings []KPIAnomaly `json:"warnings,omitempty"`
 }
 
 func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var resp KPIResponse
+	resp, err := h.buildKPI(ctx)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// buildKPI menghitung semua angka KPI (dipakai juga oleh stream dashboard).
+func (h *Handlers) buildKPI(ctx context.Context) (resp KPIResponse, err error) {
+	done := h.trackQuery(ctx, "buildKPI")
+	defer func() { done(-1, err) }()
+
 	resp.Customers.ByGender = map[string]int{}
 	resp.Customers.BySegment = map[string]int{}
 	resp.CreditApplications.ByStatus = map[string]int{}
 
 	// customers total & active
 	if err := h.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers`).Scan(&resp.Customers.Total); err != nil {
-		writeError(w, http.StatusInternalServerError, "count customers failed", err)
-		return
+		return resp, &queryError{Message: "count customers failed", Err: err}
 	}
 	if err := h.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers WHERE status = 'Active'`).Scan(&resp.Customers.Active); err != nil {
-		writeError(w, http.StatusInternalServerError, "count active customers failed", err)
-		return
+		return resp, &queryError{Message: "count active customers failed", Err: err}
 	}
 
 	// by gender
@@ -130,8 +144,7 @@ func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 			var k sql.NullString
 			var v int
 			if err := rows.Scan(&k, &v); err != nil {
-				writeError(w, http.StatusInternalServerError, "scan customers by_gender failed", err)
-				return
+				return resp, &queryError{Message: "scan customers by_gender failed", Err: err}
 			}
 			key := "Unknown"
 			if k.Valid && k.String != "" {
@@ -140,12 +153,10 @@ func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 			resp.Customers.ByGender[key] = v
 		}
 		if err := rows.Err(); err != nil {
-			writeError(w, http.StatusInternalServerError, "iterate customers by_gender failed", err)
-			return
+			return resp, &queryError{Message: "iterate customers by_gender failed", Err: err}
 		}
 	} else {
-		writeError(w, http.StatusInternalServerError, "query customers by_gender failed", err)
-		return
+		return resp, &queryError{Message: "query customers by_gender failed", Err: err}
 	}
 
 	// by segment
@@ -155,8 +166,7 @@ func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 			var k sql.NullString
 			var v int
 			if err := rows.Scan(&k, &v); err != nil {
-				writeError(w, http.StatusInternalServerError, "scan customers by_segment failed", err)
-				return
+				return resp, &queryError{Message: "scan customers by_segment failed", Err: err}
 			}
 			key := "Unknown"
 			if k.Valid && k.String != "" {
@@ -165,18 +175,15 @@ func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 			resp.Customers.BySegment[key] = v
 		}
 		if err := rows.Err(); err != nil {
-			writeError(w, http.StatusInternalServerError, "iterate customers by_segment failed", err)
-			return
+			return resp, &queryError{Message: "iterate customers by_segment failed", Err: err}
 		}
 	} else {
-		writeError(w, http.StatusInternalServerError, "query customers by_segment failed", err)
-		return
+		return resp, &queryError{Message: "query customers by_segment failed", Err: err}
 	}
 
 	// credit applications total
 	if err := h.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM credit_applications`).Scan(&resp.CreditApplications.Total); err != nil {
-		writeError(w, http.StatusInternalServerError, "count credit_applications failed", err)
-		return
+		return resp, &queryError{Message: "count credit_applications failed", Err: err}
 	}
 
 	// credit applications by status
@@ -186,8 +193,7 @@ func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 			var k sql.NullString
 			var v int
 			if err := rows.Scan(&k, &v); err != nil {
-				writeError(w, http.StatusInternalServerError, "scan credit_applications by_status failed", err)
-				return
+				return resp, &queryError{Message: "scan credit_applications by_status failed", Err: err}
 			}
 			key := "Unknown"
 			if k.Valid && k.String != "" {
@@ -196,19 +202,18 @@ func (h *Handlers) GetKPI(w http.ResponseWriter, r *http.Request) {
 			resp.CreditApplications.ByStatus[key] = v
 		}
 		if err := rows.Err(); err != nil {
-			writeError(w, http.StatusInternalServerError, "iterate credit_applications by_status failed", err)
-			return
+			return resp, &queryError{Message: "iterate credit_applications by_status failed", Err: err}
 		}
 	} else {
-		writeError(w, http.StatusInternalServerError, "query credit_applications by_status failed", err)
-		return
+		return resp, &queryError{Message: "query credit_applications by_status failed", Err: err}
 	}
 
 	// vehicles total
 	if err := h.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM vehicle_ownership`).Scan(&resp.VehicleOwnership.Total); err != nil {
-		writeError(w, http.StatusInternalServerError, "count vehicle_ownership failed", err)
-		return
+		return resp, &queryError{Message: "count vehicle_ownership failed", Err: err}
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	resp.Warnings = h.observeKPICounts(resp.Customers.Total, resp.CreditApplications.Total)
+
+	return resp, nil
 }
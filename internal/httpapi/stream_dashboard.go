@@ -0,0 +1,370 @@
+// internal/httpapi/stream_dashboard.go
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/rbac"
+)
+
+// DashboardStreamConfig mengatur SSE /stream/dashboard.
+type DashboardStreamConfig struct {
+	MaxSubscribers    int           // batas koneksi SSE bersamaan
+	SyncPollInterval  time.Duration // seberapa sering sync health dievaluasi
+	KPIInterval       time.Duration // seberapa sering KPI dihitung ulang
+	HeartbeatInterval time.Duration // komentar keep-alive supaya proxy tidak memutus koneksi
+	BufferSize        int           // jumlah event terakhir yang disimpan untuk resume Last-Event-ID
+}
+
+type streamEvent struct {
+	ID    uint64
+	Event string // sync_health | kpi
+	Data  []byte
+}
+
+// KPIUpdate dikirim sebagai event "kpi": snapshot lengkap + angka yang berubah.
+type KPIUpdate struct {
+	KPI   KPIResponse    `json:"kpi"`
+	Delta map[string]int `json:"delta"` // key datar, mis. "customers.total" atau "credit_applications.by_status.Approved"
+}
+
+// DashboardHub melakukan polling sekali untuk semua subscriber lalu fan-out lewat SSE,
+// jadi jumlah tab dashboard yang terbuka tidak menambah beban query.
+type DashboardHub struct {
+	cfg        DashboardStreamConfig
+	syncHealth func(ctx context.Context) (SyncHealthResponse, error)
+	kpi        func(ctx context.Context) (KPIResponse, error)
+	logger     *slog.Logger // diganti logger dari Start; dipakai goroutine polling saja
+
+	mu          sync.Mutex
+	nextID      uint64
+	buffer      []streamEvent
+	subscribers map[chan streamEvent]struct{}
+	lastSync    *streamEvent
+	lastKPI     *streamEvent
+	lastSyncKey string
+	lastKPIFlat map[string]int
+}
+
+func NewDashboardHub(h *Handlers, cfg DashboardStreamConfig) *DashboardHub {
+	if cfg.MaxSubscribers <= 0 {
+		cfg.MaxSubscribers = 100
+	}
+	if cfg.SyncPollInterval <= 0 {
+		cfg.SyncPollInterval = 2 * time.Second
+	}
+	if cfg.KPIInterval <= 0 {
+		cfg.KPIInterval = 30 * time.Second
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = 15 * time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 256
+	}
+	return &DashboardHub{
+		cfg:         cfg,
+		syncHealth:  h.buildSyncHealth,
+		kpi:         h.buildKPI,
+		logger:      slog.Default(),
+		subscribers: map[chan streamEvent]struct{}{},
+	}
+}
+
+// Start menjalankan polling sampai ctx selesai. Polling dilewati kalau tidak ada subscriber.
+func (d *DashboardHub) Start(ctx context.Context, logger *slog.Logger) {
+	if logger != nil {
+		d.logger = logger
+	}
+	syncT := time.NewTicker(d.cfg.SyncPollInterval)
+	kpiT := time.NewTicker(d.cfg.KPIInterval)
+	defer syncT.Stop()
+	defer kpiT.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-syncT.C:
+			if d.subscriberCount() > 0 {
+				d.pollSyncHealth(ctx)
+			}
+		case <-kpiT.C:
+			if d.subscriberCount() > 0 {
+				d.pollKPI(ctx)
+			}
+		}
+	}
+}
+
+func (d *DashboardHub) pollSyncHealth(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := d.syncHealth(ctx)
+	if err != nil {
+		d.logger.Error("dashboard stream: sync health failed", "error", err)
+		return
+	}
+
+	// hanya kirim kalau status atau lag berubah
+	key := resp.Status + "|"
+	if resp.LagSeconds != nil {
+		key += strconv.Itoa(*resp.LagSeconds)
+	}
+
+	d.mu.Lock()
+	changed := d.lastSyncKey != key || d.lastSync == nil
+	d.lastSyncKey = key
+	d.mu.Unlock()
+
+	if changed {
+		d.publish("sync_health", resp)
+	}
+}
+
+func (d *DashboardHub) pollKPI(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := d.kpi(ctx)
+	if err != nil {
+		d.logger.Error("dashboard stream: kpi failed", "error", err)
+		return
+	}
+
+	flat := flattenKPI(resp)
+
+	d.mu.Lock()
+	prev := d.lastKPIFlat
+	first := d.lastKPI == nil
+	d.lastKPIFlat = flat
+	d.mu.Unlock()
+
+	delta := map[string]int{}
+	for k, v := range flat {
+		if v != prev[k] {
+			delta[k] = v - prev[k]
+		}
+	}
+	for k, v := range prev {
+		if _, ok := flat[k]; !ok {
+			delta[k] = -v
+		}
+	}
+
+	if first || len(delta) > 0 || len(resp.Warnings) > 0 {
+		d.publish("kpi", KPIUpdate{KPI: resp, Delta: delta})
+	}
+}
+
+func (d *DashboardHub) publish(event string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		d.logger.Error("dashboard stream: marshal failed", "event", event, "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.nextID++
+	ev := streamEvent{ID: d.nextID, Event: event, Data: data}
+	d.buffer = append(d.buffer, ev)
+	if len(d.buffer) > d.cfg.BufferSize {
+		d.buffer = d.buffer[len(d.buffer)-d.cfg.BufferSize:]
+	}
+	switch event {
+	case "sync_health":
+		d.lastSync = &ev
+	case "kpi":
+		d.lastKPI = &ev
+	}
+
+	for ch := range d.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// subscriber terlalu lambat: putus, client akan reconnect dengan Last-Event-ID
+			delete(d.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (d *DashboardHub) subscriberCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.subscribers)
+}
+
+// subscribe mendaftarkan subscriber baru dan mengembalikan event yang perlu dikirim lebih dulu:
+// event setelah lastID kalau masih ada di buffer, atau snapshot terbaru kalau tidak.
+func (d *DashboardHub) subscribe(lastID uint64, hasLastID bool) (chan streamEvent, []streamEvent, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.subscribers) >= d.cfg.MaxSubscribers {
+		return nil, nil, false
+	}
+
+	var backlog []streamEvent
+	// lastID > nextID berarti id dari proses server sebelumnya (restart): kirim snapshot saja
+	if hasLastID && lastID <= d.nextID && len(d.buffer) > 0 && lastID+1 >= d.buffer[0].ID {
+		for _, ev := range d.buffer {
+			if ev.ID > lastID {
+				backlog = append(backlog, ev)
+			}
+		}
+	} else {
+		for _, ev := range []*streamEvent{d.lastSync, d.lastKPI} {
+			if ev != nil {
+				backlog = append(backlog, *ev)
+			}
+		}
+	}
+
+	ch := make(chan streamEvent, 32)
+	d.subscribers[ch] = struct{}{}
+	return ch, backlog, true
+}
+
+func (d *DashboardHub) unsubscribe(ch chan streamEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.subscribers[ch]; ok {
+		delete(d.subscribers, ch)
+		close(ch)
+	}
+}
+
+// primeIfEmpty memastikan subscriber pertama langsung mendapat data tanpa menunggu tick.
+// Dipanggil setelah subscribe, karena event hasil poll dikirim lewat broadcast.
+func (d *DashboardHub) primeIfEmpty(ctx context.Context) {
+	d.mu.Lock()
+	needSync, needKPI := d.lastSync == nil, d.lastKPI == nil
+	d.mu.Unlock()
+	if needSync {
+		d.pollSyncHealth(ctx)
+	}
+	if needKPI {
+		d.pollKPI(ctx)
+	}
+}
+
+// StreamDashboard serves:
+//
+//	GET /api/v1/stream/dashboard   (text/event-stream)
+//
+// Event: "sync_health" (SyncHealthResponse, saat status/lag berubah) dan
+// "kpi" (KPIUpdate, tiap interval kalau ada perubahan). Mendukung header Last-Event-ID.
+// Route cukup stats:read; "sync_health" hanya dikirim kalau principal juga punya sync:read
+// (sama dengan GET /sync/health).
+func (h *Handlers) StreamDashboard(w http.ResponseWriter, r *http.Request) {
+	d := h.Dashboard
+	if d == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "dashboard stream is disabled"})
+		return
+	}
+	syncAllowed := h.RBAC == nil || h.RBAC.Decide(auth.PrincipalFrom(r.Context()), rbac.PermSyncRead).Allowed
+	send := func(ev streamEvent) {
+		if ev.Event == "sync_health" && !syncAllowed {
+			return
+		}
+		writeSSE(w, ev)
+	}
+
+	rc := http.NewResponseController(w)
+
+	lastIDRaw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if lastIDRaw == "" {
+		lastIDRaw = r.URL.Query().Get("last_event_id") // untuk client yang tidak bisa set header
+	}
+	lastID, err := strconv.ParseUint(lastIDRaw, 10, 64)
+	hasLastID := lastIDRaw != "" && err == nil
+
+	ch, backlog, ok := d.subscribe(lastID, hasLastID)
+	if !ok {
+		w.Header().Set("Retry-After", "10")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "too many dashboard subscribers"})
+		return
+	}
+	defer d.unsubscribe(ch)
+
+	// prime hanya setelah subscribe diterima (client yang ditolak tidak boleh memicu query);
+	// hasil poll di-broadcast, jadi ikut sampai ke ch
+	if !hasLastID {
+		d.primeIfEmpty(r.Context())
+	}
+
+	// stream tidak boleh kena write deadline server
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: jangan buffer SSE
+	w.WriteHeader(http.StatusOK)
+
+	// retry: saran jeda reconnect untuk EventSource
+	fmt.Fprintf(w, "retry: 3000\n\n")
+	for _, ev := range backlog {
+		send(ev)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	hb := time.NewTicker(d.cfg.HeartbeatInterval)
+	defer hb.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			send(ev)
+		case t := <-hb.C:
+			fmt.Fprintf(w, ": ping %s\n\n", t.UTC().Format(time.RFC3339))
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, ev streamEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Event, ev.Data)
+}
+
+// flattenKPI mengubah KPIResponse menjadi map datar supaya delta mudah dihitung.
+func flattenKPI(k KPIResponse) map[string]int {
+	m := map[string]int{
+		"customers.total":           k.Customers.Total,
+		"customers.active":          k.Customers.Active,
+		"credit_applications.total": k.CreditApplications.Total,
+		"vehicle_ownership.total":   k.VehicleOwnership.Total,
+	}
+	for g, v := range k.Customers.ByGender {
+		m["customers.by_gender."+g] = v
+	}
+	for s, v := range k.Customers.BySegment {
+		m["customers.by_segment."+s] = v
+	}
+	for s, v := range k.CreditApplications.ByStatus {
+		m["credit_applications.by_status."+s] = v
+	}
+	return m
+}
@@ -0,0 +1,56 @@
+// internal/httpapi/stream_dashboard_test.go
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mini-poc-02/backend/internal/auth"
+	"mini-poc-02/backend/internal/rbac"
+)
+
+func TestStreamDashboardSyncHealthNeedsSyncRead(t *testing.T) {
+	enforcer, err := rbac.NewEnforcer(rbac.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		rbac     *rbac.Enforcer
+		roles    []string
+		wantSync bool
+	}{
+		{name: "stats only", rbac: enforcer, roles: []string{"analyst"}, wantSync: false},
+		{name: "stats and sync", rbac: enforcer, roles: []string{"ops"}, wantSync: true},
+		{name: "RBAC disabled", rbac: nil, wantSync: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handlers{RBAC: tt.rbac}
+			h.Dashboard = NewDashboardHub(h, DashboardStreamConfig{})
+			// snapshot sudah ada: subscriber baru menerimanya sebagai backlog tanpa polling
+			h.Dashboard.publish("sync_health", map[string]any{"status": "ok"})
+			h.Dashboard.publish("kpi", map[string]any{"customers": 1})
+
+			// context sudah selesai: handler menulis backlog lalu berhenti
+			ctx, cancel := context.WithCancel(auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "u1", Roles: tt.roles}))
+			cancel()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stream/dashboard", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			h.StreamDashboard(rec, req)
+
+			body := rec.Body.String()
+			if !strings.Contains(body, "event: kpi") {
+				t.Errorf("kpi event missing:\n%s", body)
+			}
+			if got := strings.Contains(body, "event: sync_health"); got != tt.wantSync {
+				t.Errorf("sync_health sent = %v, want %v:\n%s", got, tt.wantSync, body)
+			}
+		})
+	}
+}
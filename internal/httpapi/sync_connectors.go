@@ -0,0 +1,90 @@
+// internal/httpapi/sync_connectors.go
+package httpapi
+
+import (
+	"context"
+	"fmt"
+
+	"mini-poc-02/backend/internal/kafkaconnect"
+)
+
+// ConnectorProbe mengecek connector Debezium source / JDBC sink via Kafka Connect REST API.
+type ConnectorProbe struct {
+	Client     *kafkaconnect.Client
+	Connectors []string // nama connector yang wajib RUNNING
+}
+
+type ConnectorHealth struct {
+	Name    string                   `json:"name"`
+	Type    string                   `json:"type,omitempty"`
+	State   string                   `json:"state,omitempty"` // state connector dari Kafka Connect
+	Trace   string                   `json:"trace,omitempty"`
+	Tasks   []kafkaconnect.TaskState `json:"tasks"`
+	Status  string                   `json:"status"` // ok | warn | error (verdict backend)
+	Message string                   `json:"message,omitempty"`
+}
+
+// addConnectorHealth mem-probe tiap connector dan menggabungkan hasilnya ke status utama.
+// Task FAILED (atau connector FAILED) => error; PAUSED/UNASSIGNED/RESTARTING atau probe gagal => warn.
+func (h *Handlers) addConnectorHealth(ctx context.Context, resp *SyncHealthResponse) {
+	if h.Connectors == nil || h.Connectors.Client == nil || len(h.Connectors.Connectors) == 0 {
+		return
+	}
+
+	resp.Connectors = make([]ConnectorHealth, 0, len(h.Connectors.Connectors))
+	for _, name := range h.Connectors.Connectors {
+		ch := probeConnector(ctx, h.Connectors.Client, name)
+		resp.Connectors = append(resp.Connectors, ch)
+		resp.Status = worseStatus(resp.Status, ch.Status)
+	}
+}
+
+func probeConnector(ctx context.Context, c *kafkaconnect.Client, name string) ConnectorHealth {
+	ch := ConnectorHealth{Name: name, Tasks: []kafkaconnect.TaskState{}}
+
+	st, err := c.ConnectorStatus(ctx, name)
+	if err != nil {
+		ch.Status = "warn"
+		ch.Message = "status probe failed: " + err.Error()
+		return ch
+	}
+
+	ch.Type = st.Type
+	ch.State = st.Connector.State
+	ch.Trace = st.Connector.Trace
+	if st.Tasks != nil {
+		ch.Tasks = st.Tasks
+	}
+
+	ch.Status = connectorStateStatus(st.Connector.State)
+	if ch.Status != "ok" {
+		ch.Message = fmt.Sprintf("connector %s is %s", name, st.Connector.State)
+	}
+
+	failed := 0
+	for _, t := range st.Tasks {
+		s := connectorStateStatus(t.State)
+		if t.State == kafkaconnect.StateFailed {
+			failed++
+		}
+		ch.Status = worseStatus(ch.Status, s)
+	}
+	if failed > 0 {
+		ch.Message = fmt.Sprintf("%d of %d task(s) FAILED", failed, len(st.Tasks))
+	} else if len(st.Tasks) == 0 && ch.Status == "ok" {
+		ch.Status = "warn"
+		ch.Message = "connector has no tasks"
+	}
+	return ch
+}
+
+func connectorStateStatus(state string) string {
+	switch state {
+	case kafkaconnect.StateRunning:
+		return "ok"
+	case kafkaconnect.StateFailed:
+		return "error"
+	default:
+		return "warn"
+	}
+}
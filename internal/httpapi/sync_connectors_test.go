@@ -0,0 +1,103 @@
+// internal/httpapi/sync_connectors_test.go
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mini-poc-02/backend/internal/kafkaconnect"
+)
+
+func TestAddConnectorHealth(t *testing.T) {
+	statuses := map[string]string{
+		"running": `{"name":"running","type":"source","connector":{"state":"RUNNING"},"tasks":[{"id":0,"state":"RUNNING"}]}`,
+		"task-failed": `{"name":"task-failed","type":"sink","connector":{"state":"RUNNING"},
+			"tasks":[{"id":0,"state":"RUNNING"},{"id":1,"state":"FAILED","trace":"java.sql.SQLException: boom"}]}`,
+		"connector-failed": `{"name":"connector-failed","connector":{"state":"FAILED","trace":"oops"},"tasks":[]}`,
+		"paused":           `{"name":"paused","connector":{"state":"PAUSED"},"tasks":[{"id":0,"state":"PAUSED"}]}`,
+		"no-tasks":         `{"name":"no-tasks","connector":{"state":"RUNNING"},"tasks":[]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/connectors/"), "/status")
+		body, ok := statuses[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		connectors []string
+		base       string // status sebelum connector di-probe
+		want       string
+		wantMsg    string // pesan connector terakhir
+	}{
+		{name: "all running", connectors: []string{"running"}, base: "ok", want: "ok"},
+		{name: "failed task downgrades to error", connectors: []string{"running", "task-failed"}, base: "ok", want: "error", wantMsg: "1 of 2 task(s) FAILED"},
+		{name: "failed connector", connectors: []string{"connector-failed"}, base: "ok", want: "error", wantMsg: "connector connector-failed is FAILED"},
+		{name: "paused is warn", connectors: []string{"paused"}, base: "ok", want: "warn", wantMsg: "connector paused is PAUSED"},
+		{name: "no tasks is warn", connectors: []string{"no-tasks"}, base: "ok", want: "warn", wantMsg: "connector has no tasks"},
+		{name: "probe failure is warn", connectors: []string{"missing"}, base: "ok", want: "warn", wantMsg: "status probe failed"},
+		{name: "never improves lag status", connectors: []string{"running"}, base: "error", want: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := kafkaconnect.NewClient(srv.URL, time.Second)
+			h := &Handlers{Connectors: &ConnectorProbe{Client: c, Connectors: tt.connectors}}
+			resp := &SyncHealthResponse{Status: tt.base}
+
+			h.addConnectorHealth(context.Background(), resp)
+
+			if resp.Status != tt.want {
+				t.Errorf("status = %q, want %q", resp.Status, tt.want)
+			}
+			if len(resp.Connectors) != len(tt.connectors) {
+				t.Fatalf("connectors = %d, want %d", len(resp.Connectors), len(tt.connectors))
+			}
+			last := resp.Connectors[len(resp.Connectors)-1]
+			if !strings.HasPrefix(last.Message, tt.wantMsg) {
+				t.Errorf("message = %q, want prefix %q", last.Message, tt.wantMsg)
+			}
+			if last.Tasks == nil {
+				t.Error("tasks must be [] rather than null")
+			}
+		})
+	}
+}
+
+func TestAddConnectorHealthReportsTaskTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"sink","type":"sink","connector":{"state":"RUNNING","worker_id":"w1"},
+			"tasks":[{"id":0,"state":"FAILED","worker_id":"w1","trace":"java.sql.SQLException: boom"}]}`))
+	}))
+	defer srv.Close()
+
+	h := &Handlers{Connectors: &ConnectorProbe{Client: kafkaconnect.NewClient(srv.URL, time.Second), Connectors: []string{"sink"}}}
+	resp := &SyncHealthResponse{Status: "ok"}
+	h.addConnectorHealth(context.Background(), resp)
+
+	ch := resp.Connectors[0]
+	if ch.Type != "sink" || ch.State != kafkaconnect.StateRunning || ch.Status != "error" {
+		t.Errorf("connector = %+v", ch)
+	}
+	if len(ch.Tasks) != 1 || ch.Tasks[0].Trace != "java.sql.SQLException: boom" || ch.Tasks[0].WorkerID != "w1" {
+		t.Errorf("tasks = %+v", ch.Tasks)
+	}
+}
+
+func TestAddConnectorHealthDisabled(t *testing.T) {
+	for _, h := range []*Handlers{{}, {Connectors: &ConnectorProbe{}}} {
+		resp := &SyncHealthResponse{Status: "ok"}
+		h.addConnectorHealth(context.Background(), resp)
+		if resp.Connectors != nil || resp.Status != "ok" {
+			t.Errorf("disabled probe changed response: %+v", resp)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// internal/httpapi/sync_freshness.go
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TableFreshness menunjukkan kapan sebuah tabel terakhir menerima perubahan dari sink.
+type TableFreshness struct {
+	Table       string     `json:"table"`
+	Column      string     `json:"column"` // kolom timestamp yang dipakai (last_updated / created_date)
+	RowCount    int        `json:"row_count"`
+	LatestAt    *time.Time `json:"latest_at"`
+	AgeSeconds  *int       `json:"age_seconds"`
+	Status      string     `json:"status"` // ok | warn | error
+	Policy      string     `json:"policy"` // SLA policy yang dipakai (default / nama tabel)
+	Explanation string     `json:"explanation,omitempty"`
+}
+
+// freshnessTables: nama tabel & kolom di-hardcode (bukan input user), aman dipakai di fmt.Sprintf.
+var freshnessTables = []struct {
+	Table  string
+	Column string
+}{
+	{"customers", "last_updated"},
+	{"credit_applications", "created_date"},
+	{"vehicle_ownership", "created_date"},
+}
+
+// addTableFreshness mengecek freshness tiap tabel dan menggabungkannya ke status utama.
+func (h *Handlers) addTableFreshness(ctx context.Context, resp *SyncHealthResponse) {
+	now := time.Now()
+	resp.Tables = make([]TableFreshness, 0, len(freshnessTables))
+
+	for _, t := range freshnessTables {
+		p := h.SLA.For(t.Table)
+		tf, err := h.getTableFreshness(ctx, t.Table, t.Column, p, now)
+		if err != nil {
+			tf = TableFreshness{
+				Table:       t.Table,
+				Column:      t.Column,
+				Status:      "error",
+				Policy:      p.Name,
+				Explanation: "freshness query failed: " + err.Error(),
+			}
+		}
+		resp.Tables = append(resp.Tables, tf)
+		resp.Status = worseStatus(resp.Status, tf.Status)
+	}
+}
+
+func (h *Handlers) getTableFreshness(ctx context.Context, table, column string, p SLAPolicy, now time.Time) (TableFreshness, error) {
+	tf := TableFreshness{Table: table, Column: column, Policy: p.Name}
+
+	q := fmt.Sprintf(`SELECT COUNT(*), MAX(%s) FROM %s`, column, table)
+	var latest sql.NullTime
+	done := h.trackQuery(ctx, "getTableFreshness")
+	err := h.DB.QueryRowContext(ctx, q).Scan(&tf.RowCount, &latest)
+	done(1, err)
+	if err != nil {
+		return tf, err
+	}
+
+	if !latest.Valid {
+		tf.Status = "warn"
+		tf.Explanation = table + " is empty"
+		return tf, nil
+	}
+
+	tf.LatestAt = &latest.Time
+	age := now.Sub(latest.Time)
+	if age < 0 {
+		age = 0
+	}
+	secs := int(age / time.Second)
+	tf.AgeSeconds = &secs
+
+	warnAfter := time.Duration(p.FreshnessWarnSeconds) * time.Second
+	errorAfter := time.Duration(p.FreshnessErrorSeconds) * time.Second
+
+	tf.Status = "ok"
+	switch {
+	case errorAfter > 0 && age > errorAfter:
+		tf.Status = "error"
+		tf.Explanation = fmt.Sprintf("no change in %s for %s (error after %s)", table, age.Round(time.Second), errorAfter)
+	case warnAfter > 0 && age > warnAfter:
+		tf.Status = "warn"
+		tf.Explanation = fmt.Sprintf("no change in %s for %s (warn after %s)", table, age.Round(time.Second), warnAfter)
+	}
+	return tf, nil
+}
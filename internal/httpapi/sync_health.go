@@ -118,6 +118,8 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"mini-poc-02/backend/internal/heartbeat"
 )
 
 type SyncHealthResponse struct {
@@ -132,18 +134,57 @@ type SyncHealthResponse struct {
 	LastSuccessAt *time.Time `json:"last_success_at"`
 	LastError     *string    `json:"last_error"`
 
-	// untuk “success criteria” demo
+	// untuk “success criteria” demo (= lag_warn_seconds policy yang dipakai)
 	SLATargetSeconds int `json:"sla_target_seconds"`
+
+	// Policy SLA yang diterapkan dan rule yang menentukan status
+	SLA SLAVerdict `json:"sla"`
+
+	// Freshness per tabel ODS (apakah tiap tabel benar-benar menerima perubahan)
+	Tables []TableFreshness `json:"tables"`
+
+	// Heartbeat terakhir yang diukur backend sendiri (kalau HEARTBEAT_INTERVAL di-set)
+	Heartbeat *heartbeat.Result `json:"heartbeat,omitempty"`
+
+	// Status connector Kafka Connect (hanya kalau KAFKA_CONNECT_URL di-set)
+	Connectors []ConnectorHealth `json:"connectors,omitempty"`
+
+	// Temuan tambahan di luar baris sync_audit (mis. anomali row count)
+	Warnings []SyncWarning `json:"warnings,omitempty"`
+}
+
+// SyncWarning adalah temuan yang menurunkan status minimal ke "warn".
+type SyncWarning struct {
+	Code    string `json:"code"` // mis. kpi_anomaly
+	Message string `json:"message"`
 }
 
 func (h *Handlers) GetSyncHealth(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	resp, err := h.buildSyncHealth(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query sync_audit failed", err)
+		return
+	}
+
+	// response
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(resp)
+}
+
+// buildSyncHealth menjalankan semua rule sync health (sync_audit terbaru, freshness
+// per tabel, status connector, anomali KPI) dan menghasilkan verdict ok|warn|error
+// berdasarkan SLA policy yang dikonfigurasi.
+func (h *Handlers) buildSyncHealth(ctx context.Context) (SyncHealthResponse, error) {
 	resp := SyncHealthResponse{
-		SLATargetSeconds: 10,
-		Status:           "warn", // default: warn kalau audit belum ada / belum stabil
+		Status: "warn", // default: warn kalau audit belum ada / belum stabil
 	}
+	resp.SLA.Policy = h.SLA.Default
+	resp.SLA.Checks = []SLACheck{}
 
 	// gunakan sql.Null* agar aman untuk NULL dari Postgres
 	var (
@@ -152,8 +193,10 @@ func (h *Handlers) GetSyncHealth(w http.ResponseWriter, r *http.Request) {
 		lagSeconds    sql.NullInt64
 		lastSuccessAt sql.NullTime
 		lastError     sql.NullString
+		createdAt     time.Time
 	)
 
+	done := h.trackQuery(ctx, "latestSyncAudit")
 	err := h.DB.QueryRowContext(ctx, `
 		SELECT
 			tool_name,
@@ -163,7 +206,8 @@ func (h *Handlers) GetSyncHealth(w http.ResponseWriter, r *http.Request) {
 			last_target_ts,
 			lag_seconds,
 			last_success_at,
-			last_error
+			last_error,
+			created_at
 		FROM sync_audit
 		ORDER BY created_at DESC
 		LIMIT 1
@@ -176,48 +220,85 @@ func (h *Handlers) GetSyncHealth(w http.ResponseWriter, r *http.Request) {
 		&lagSeconds,
 		&lastSuccessAt,
 		&lastError,
+		&createdAt,
 	)
+	done(1, err)
 
-	// Kalau tabel kosong / belum ada data audit: return warn agar UI bisa kasih instruksi.
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			writeJSON(w, http.StatusOK, resp)
-			return
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Kalau tabel kosong / belum ada data audit: tetap warn agar UI bisa kasih instruksi.
+		resp.SLA.Rule = "no_audit_rows"
+		resp.SLA.Message = "sync_audit has no rows yet"
+		resp.SLA.Checks = append(resp.SLA.Checks, SLACheck{Rule: "no_audit_rows", Status: "warn", Message: resp.SLA.Message})
+	case err != nil:
+		return resp, err
+	default:
+		// convert nullable -> pointer (sesuai JSON)
+		if lastSourceTS.Valid {
+			resp.LastSourceTS = &lastSourceTS.Time
+		}
+		if lastTargetTS.Valid {
+			resp.LastTargetTS = &lastTargetTS.Time
+		}
+		if lagSeconds.Valid {
+			v := int(lagSeconds.Int64)
+			resp.LagSeconds = &v
+		}
+		if lastSuccessAt.Valid {
+			resp.LastSuccessAt = &lastSuccessAt.Time
+		}
+		if lastError.Valid {
+			s := lastError.String
+			resp.LastError = &s
 		}
-		writeError(w, http.StatusInternalServerError, "query sync_audit failed", err)
-		return
-	}
 
-	// convert nullable -> pointer (sesuai JSON)
-	if lastSourceTS.Valid {
-		resp.LastSourceTS = &lastSourceTS.Time
+		// policy mengikuti tabel target baris audit (override per tabel kalau ada)
+		resp.SLA.Policy = h.SLA.For(resp.TargetName)
+		evaluateAudit(&resp, createdAt, resp.SLA.Policy, time.Now())
 	}
-	if lastTargetTS.Valid {
-		resp.LastTargetTS = &lastTargetTS.Time
-	}
-	if lagSeconds.Valid {
-		v := int(lagSeconds.Int64)
-		resp.LagSeconds = &v
+	resp.SLATargetSeconds = resp.SLA.Policy.LagWarnSeconds
+	auditStatus := resp.Status
+
+	if h.Heartbeat != nil {
+		resp.Heartbeat = h.Heartbeat.Last()
 	}
-	if lastSuccessAt.Valid {
-		resp.LastSuccessAt = &lastSuccessAt.Time
+
+	h.addTableFreshness(ctx, &resp)
+	h.addConnectorHealth(ctx, &resp)
+	h.addSyncWarnings(ctx, &resp)
+	explainVerdict(&resp, auditStatus)
+
+	return resp, nil
+}
+
+// addSyncWarnings menambahkan temuan best-effort ke response dan menurunkan
+// status ok -> warn kalau ada. Status error tidak diubah.
+func (h *Handlers) addSyncWarnings(ctx context.Context, resp *SyncHealthResponse) {
+	anomalies, err := h.checkKPIAnomalies(ctx)
+	if err != nil {
+		resp.Warnings = append(resp.Warnings, SyncWarning{
+			Code:    "kpi_anomaly_check_failed",
+			Message: "count check failed: " + err.Error(),
+		})
 	}
-	if lastError.Valid {
-		s := lastError.String
-		resp.LastError = &s
+	for _, a := range anomalies {
+		resp.Warnings = append(resp.Warnings, SyncWarning{
+			Code:    "kpi_anomaly",
+			Message: a.Message,
+		})
 	}
 
-	// hitung status simple
-	resp.Status = "ok"
-	if resp.LastError != nil && *resp.LastError != "" {
-		resp.Status = "error"
-	} else if resp.LagSeconds != nil && *resp.LagSeconds > resp.SLATargetSeconds {
-		resp.Status = "warn"
+	if len(resp.Warnings) > 0 {
+		resp.Status = worseStatus(resp.Status, "warn")
 	}
+}
 
-	// response
-	w.Header().Set("Content-Type", "application/json")
-	enc := json.NewEncoder(w)
-	enc.SetEscapeHTML(false)
-	_ = enc.Encode(resp)
+var statusRank = map[string]int{"ok": 0, "warn": 1, "error": 2}
+
+// worseStatus mengembalikan status yang lebih buruk dari a dan b (ok < warn < error).
+func worseStatus(a, b string) string {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
 }
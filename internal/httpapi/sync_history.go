@@ -0,0 +1,319 @@
+// internal/httpapi/sync_history.go
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	syncHistoryDefaultRange  = 24 * time.Hour
+	syncHistoryDefaultBucket = time.Hour
+	syncHistoryMaxBuckets    = 2000
+)
+
+type LagPercentiles struct {
+	P50 *int `json:"p50"`
+	P95 *int `json:"p95"`
+	P99 *int `json:"p99"`
+	Max *int `json:"max"`
+}
+
+type SyncHistoryBucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	Samples      int            `json:"samples"`     // jumlah baris sync_audit di bucket
+	LagSamples   int            `json:"lag_samples"` // baris dengan lag_seconds tidak NULL
+	Lag          LagPercentiles `json:"lag_seconds"`
+	WithinSLA    int            `json:"within_sla"`
+	WithinSLAPct *float64       `json:"within_sla_pct"`
+	ErrorCount   int            `json:"error_count"`
+}
+
+type BreachWindow struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int       `json:"duration_seconds"`
+	Samples         int       `json:"samples"`
+	MaxLagSeconds   *int      `json:"max_lag_seconds"`
+	Ongoing         bool      `json:"ongoing"` // true kalau breach masih berlangsung di akhir range
+}
+
+type SyncHistoryResponse struct {
+	From             time.Time `json:"from"`
+	To               time.Time `json:"to"`
+	BucketSeconds    int       `json:"bucket_seconds"`
+	SLATargetSeconds int       `json:"sla_target_seconds"`
+
+	Overall SyncHistoryBucket   `json:"overall"`
+	Buckets []SyncHistoryBucket `json:"buckets"`
+
+	// Rentang terpanjang di mana sample berturut-turut melanggar SLA atau error.
+	LongestBreach *BreachWindow `json:"longest_breach"`
+}
+
+// syncHistoryRow adalah agregat satu bucket dari sync_audit; Bucket nil = seluruh range.
+type syncHistoryRow struct {
+	Bucket     *int
+	Samples    int
+	LagSamples int
+	WithinSLA  int
+	Errors     int
+	Lag        LagPercentiles
+}
+
+// breachRun adalah rangkaian sample breach berturut-turut; End nil = belum ada sample sehat sesudahnya.
+type breachRun struct {
+	Start   time.Time
+	End     *time.Time
+	Samples int
+	MaxLag  *int
+}
+
+// GetSyncHistory serves:
+//
+//	GET /api/v1/sync/history?from=2024-01-01T00:00:00Z&to=2024-01-02T00:00:00Z&bucket=1h
+//
+// from/to menerima RFC3339 atau YYYY-MM-DD (default: 24 jam terakhir),
+// bucket menerima durasi Go ("5m", "1h"; default 1h).
+func (h *Handlers) GetSyncHistory(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+
+	to, err := parseTimeParam(r.URL.Query().Get("to"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to", err)
+		return
+	}
+	from, err := parseTimeParam(r.URL.Query().Get("from"), to.Add(-syncHistoryDefaultRange))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from", err)
+		return
+	}
+	if !from.Before(to) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "from must be before to"})
+		return
+	}
+
+	bucket := syncHistoryDefaultBucket
+	if s := strings.TrimSpace(r.URL.Query().Get("bucket")); s != "" {
+		bucket, err = time.ParseDuration(s)
+		if err != nil || bucket < time.Second {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "bucket must be a duration >= 1s (e.g. 5m, 1h)"})
+			return
+		}
+	}
+	if n := int(to.Sub(from) / bucket); n > syncHistoryMaxBuckets {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error": fmt.Sprintf("too many buckets (%d); use a larger bucket or a shorter range", n),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	sla := h.SLA.Default.LagWarnSeconds
+	rows, err := h.getSyncHistoryRows(ctx, from, to, bucket, sla)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query sync_audit failed", err)
+		return
+	}
+	run, err := h.getLongestBreachRun(ctx, from, to, sla)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query sync_audit failed", err)
+		return
+	}
+
+	resp := buildSyncHistory(rows, from, to, bucket, sla)
+	resp.LongestBreach = breachWindow(run, to)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// getSyncHistoryRows menghitung count dan percentil lag per bucket plus satu baris total
+// (GROUPING SETS) di Postgres, jadi berapa pun jumlah baris sync_audit di range tidak
+// ada yang dibawa ke memori. percentile_disc = nearest-rank, NULL lag diabaikan.
+func (h *Handlers) getSyncHistoryRows(ctx context.Context, from, to time.Time, bucket time.Duration, slaSeconds int) (out []syncHistoryRow, err error) {
+	done := h.trackQuery(ctx, "getSyncHistoryRows")
+	defer func() { done(len(out), err) }()
+
+	const q = `
+		SELECT
+			bucket,
+			COUNT(*),
+			COUNT(lag_seconds),
+			COUNT(*) FILTER (WHERE lag_seconds <= $4::int),
+			COUNT(*) FILTER (WHERE has_error),
+			percentile_disc(0.50) WITHIN GROUP (ORDER BY lag_seconds),
+			percentile_disc(0.95) WITHIN GROUP (ORDER BY lag_seconds),
+			percentile_disc(0.99) WITHIN GROUP (ORDER BY lag_seconds),
+			MAX(lag_seconds)
+		FROM (
+			SELECT
+				FLOOR(EXTRACT(EPOCH FROM created_at - $1::timestamptz) / $3::float8)::int AS bucket,
+				lag_seconds,
+				COALESCE(last_error, '') <> '' AS has_error
+			FROM sync_audit
+			WHERE created_at >= $1::timestamptz AND created_at < $2::timestamptz
+		) s
+		GROUP BY GROUPING SETS ((bucket), ())
+		ORDER BY bucket NULLS FIRST
+	`
+
+	rows, err := h.DB.QueryContext(ctx, q, from, to, bucket.Seconds(), slaSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row syncHistoryRow
+		var b, p50, p95, p99, mx sql.NullInt64
+		if err := rows.Scan(&b, &row.Samples, &row.LagSamples, &row.WithinSLA, &row.Errors, &p50, &p95, &p99, &mx); err != nil {
+			return nil, err
+		}
+		row.Bucket = nullIntPtr(b)
+		row.Lag = LagPercentiles{P50: nullIntPtr(p50), P95: nullIntPtr(p95), P99: nullIntPtr(p99), Max: nullIntPtr(mx)}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// getLongestBreachRun mencari rangkaian sample berturut-turut yang error atau lag > SLA
+// dengan window function (gaps and islands): grp = jumlah sample sehat sampai baris ini,
+// jadi sample breach berturut-turut berbagi grp yang sama dan sample sehat yang menutupnya
+// punya grp+1. Rangkaian berakhir di sample sehat pertama setelahnya (NULL = masih breach).
+func (h *Handlers) getLongestBreachRun(ctx context.Context, from, to time.Time, slaSeconds int) (run *breachRun, err error) {
+	done := h.trackQuery(ctx, "getLongestBreachRun")
+	defer func() {
+		n := 0
+		if run != nil {
+			n = 1
+		}
+		done(n, err)
+	}()
+
+	const q = `
+		WITH s AS (
+			SELECT
+				created_at,
+				lag_seconds,
+				COALESCE(last_error, '') <> '' OR COALESCE(lag_seconds > $3::int, false) AS breach
+			FROM sync_audit
+			WHERE created_at >= $1::timestamptz AND created_at < $2::timestamptz
+		), g AS (
+			SELECT *, COUNT(*) FILTER (WHERE NOT breach) OVER (ORDER BY created_at ROWS UNBOUNDED PRECEDING) AS grp
+			FROM s
+		), runs AS (
+			SELECT grp, MIN(created_at) AS start_at, COUNT(*) AS samples, MAX(lag_seconds) AS max_lag
+			FROM g
+			WHERE breach
+			GROUP BY grp
+		)
+		SELECT r.start_at, e.created_at, r.samples, r.max_lag
+		FROM runs r
+		LEFT JOIN g e ON NOT e.breach AND e.grp = r.grp + 1
+		ORDER BY COALESCE(e.created_at, $2::timestamptz) - r.start_at DESC, r.start_at ASC
+		LIMIT 1
+	`
+
+	var r breachRun
+	var end sql.NullTime
+	var maxLag sql.NullInt64
+	err = h.DB.QueryRowContext(ctx, q, from, to, slaSeconds).Scan(&r.Start, &end, &r.Samples, &maxLag)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if end.Valid {
+		r.End = &end.Time
+	}
+	r.MaxLag = nullIntPtr(maxLag)
+	return &r, nil
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+// buildSyncHistory menyusun bucket kosong untuk seluruh range lalu mengisinya dengan agregat dari SQL.
+func buildSyncHistory(rows []syncHistoryRow, from, to time.Time, bucket time.Duration, slaSeconds int) SyncHistoryResponse {
+	resp := SyncHistoryResponse{
+		From:             from,
+		To:               to,
+		BucketSeconds:    int(bucket / time.Second),
+		SLATargetSeconds: slaSeconds,
+		Buckets:          make([]SyncHistoryBucket, 0, int(to.Sub(from)/bucket)+1),
+	}
+
+	for start := from; start.Before(to); start = start.Add(bucket) {
+		end := start.Add(bucket)
+		if end.After(to) {
+			end = to
+		}
+		resp.Buckets = append(resp.Buckets, SyncHistoryBucket{Start: start, End: end})
+	}
+	resp.Overall.Start, resp.Overall.End = from, to
+
+	for _, row := range rows {
+		b := &resp.Overall
+		if row.Bucket != nil {
+			i := *row.Bucket
+			if i < 0 || i >= len(resp.Buckets) {
+				continue
+			}
+			b = &resp.Buckets[i]
+		}
+		b.Samples = row.Samples
+		b.LagSamples = row.LagSamples
+		b.WithinSLA = row.WithinSLA
+		b.ErrorCount = row.Errors
+		b.Lag = row.Lag
+		if row.LagSamples > 0 {
+			pct := float64(row.WithinSLA) / float64(row.LagSamples) * 100
+			b.WithinSLAPct = &pct
+		}
+	}
+	return resp
+}
+
+// breachWindow mengubah hasil getLongestBreachRun jadi BreachWindow; rangkaian yang belum
+// ditutup sample sehat dianggap masih berlangsung sampai akhir range.
+func breachWindow(run *breachRun, rangeEnd time.Time) *BreachWindow {
+	if run == nil {
+		return nil
+	}
+	w := &BreachWindow{Start: run.Start, End: rangeEnd, Samples: run.Samples, MaxLagSeconds: run.MaxLag, Ongoing: true}
+	if run.End != nil {
+		w.End, w.Ongoing = *run.End, false
+	}
+	w.DurationSeconds = int(w.End.Sub(w.Start) / time.Second)
+	return w
+}
+
+// parseTimeParam menerima RFC3339 atau tanggal (YYYY-MM-DD, dianggap UTC).
+func parseTimeParam(s string, def time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 or YYYY-MM-DD, got %q", s)
+	}
+	return t.UTC(), nil
+}
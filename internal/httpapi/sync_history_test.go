@@ -0,0 +1,182 @@
+// internal/httpapi/sync_history_test.go
+package httpapi
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func intp(v int) *int { return &v }
+
+func TestBuildSyncHistory(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		to          time.Time
+		rows        []syncHistoryRow
+		wantBuckets []SyncHistoryBucket
+		wantOverall SyncHistoryBucket
+	}{
+		{
+			name: "no rows gives empty buckets",
+			to:   from.Add(2 * time.Hour),
+			wantBuckets: []SyncHistoryBucket{
+				{Start: from, End: from.Add(time.Hour)},
+				{Start: from.Add(time.Hour), End: from.Add(2 * time.Hour)},
+			},
+			wantOverall: SyncHistoryBucket{Start: from, End: from.Add(2 * time.Hour)},
+		},
+		{
+			name: "rows fill their bucket, gaps stay empty, last bucket is cut at to",
+			to:   from.Add(150 * time.Minute),
+			rows: []syncHistoryRow{
+				{Samples: 5, LagSamples: 4, WithinSLA: 3, Errors: 1, Lag: LagPercentiles{P50: intp(10), P95: intp(900), P99: intp(900), Max: intp(900)}},
+				{Bucket: intp(0), Samples: 3, LagSamples: 2, WithinSLA: 1, Lag: LagPercentiles{P50: intp(10), P95: intp(900), P99: intp(900), Max: intp(900)}},
+				{Bucket: intp(2), Samples: 2, LagSamples: 2, WithinSLA: 2, Errors: 1, Lag: LagPercentiles{P50: intp(5), P95: intp(20), P99: intp(20), Max: intp(20)}},
+				{Bucket: intp(7), Samples: 9}, // di luar range: diabaikan
+			},
+			wantBuckets: []SyncHistoryBucket{
+				{
+					Start: from, End: from.Add(time.Hour), Samples: 3, LagSamples: 2, WithinSLA: 1, WithinSLAPct: floatp(50),
+					Lag: LagPercentiles{P50: intp(10), P95: intp(900), P99: intp(900), Max: intp(900)},
+				},
+				{Start: from.Add(time.Hour), End: from.Add(2 * time.Hour)},
+				{
+					Start: from.Add(2 * time.Hour), End: from.Add(150 * time.Minute), Samples: 2, LagSamples: 2, WithinSLA: 2, WithinSLAPct: floatp(100), ErrorCount: 1,
+					Lag: LagPercentiles{P50: intp(5), P95: intp(20), P99: intp(20), Max: intp(20)},
+				},
+			},
+			wantOverall: SyncHistoryBucket{
+				Start: from, End: from.Add(150 * time.Minute), Samples: 5, LagSamples: 4, WithinSLA: 3, WithinSLAPct: floatp(75), ErrorCount: 1,
+				Lag: LagPercentiles{P50: intp(10), P95: intp(900), P99: intp(900), Max: intp(900)},
+			},
+		},
+		{
+			name: "bucket with only NULL lags has no SLA percentage",
+			to:   from.Add(time.Hour),
+			rows: []syncHistoryRow{
+				{Samples: 2, Errors: 2},
+				{Bucket: intp(0), Samples: 2, Errors: 2},
+			},
+			wantBuckets: []SyncHistoryBucket{{Start: from, End: from.Add(time.Hour), Samples: 2, ErrorCount: 2}},
+			wantOverall: SyncHistoryBucket{Start: from, End: from.Add(time.Hour), Samples: 2, ErrorCount: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSyncHistory(tt.rows, from, tt.to, time.Hour, 60)
+			if got.BucketSeconds != 3600 || got.SLATargetSeconds != 60 {
+				t.Errorf("bucket_seconds = %d, sla = %d", got.BucketSeconds, got.SLATargetSeconds)
+			}
+			if !reflect.DeepEqual(got.Buckets, tt.wantBuckets) {
+				t.Errorf("buckets =\n%s\nwant\n%s", mustJSON(t, got.Buckets), mustJSON(t, tt.wantBuckets))
+			}
+			if !reflect.DeepEqual(got.Overall, tt.wantOverall) {
+				t.Errorf("overall =\n%s\nwant\n%s", mustJSON(t, got.Overall), mustJSON(t, tt.wantOverall))
+			}
+		})
+	}
+}
+
+func TestBreachWindow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	rangeEnd := start.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		run  *breachRun
+		want *BreachWindow
+	}{
+		{name: "no breach", run: nil, want: nil},
+		{
+			name: "closed by a healthy sample",
+			run:  &breachRun{Start: start, End: &end, Samples: 3, MaxLag: intp(400)},
+			want: &BreachWindow{Start: start, End: end, DurationSeconds: 90, Samples: 3, MaxLagSeconds: intp(400)},
+		},
+		{
+			name: "ongoing until end of range",
+			run:  &breachRun{Start: start, Samples: 1}, // error tanpa lag
+			want: &BreachWindow{Start: start, End: rangeEnd, DurationSeconds: 3600, Samples: 1, Ongoing: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := breachWindow(tt.run, rangeEnd); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("breach = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSyncHistoryAggregatesInSQL(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	breachStart := from.Add(10 * time.Minute)
+
+	var historyArgs []driver.NamedValue
+	db, fake := newFakeDB(t, func(q string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(q, "percentile_disc") {
+			historyArgs = args
+			return []string{"bucket", "count", "count", "count", "count", "p50", "p95", "p99", "max"}, [][]driver.Value{
+				{nil, int64(4), int64(4), int64(3), int64(0), int64(5), int64(300), int64(300), int64(300)},
+				{int64(0), int64(1), int64(1), int64(1), int64(0), int64(5), int64(5), int64(5), int64(5)},
+				{int64(1), int64(3), int64(3), int64(2), int64(0), int64(6), int64(300), int64(300), int64(300)},
+			}, nil
+		}
+		// breach masih berlangsung: belum ada sample sehat yang menutupnya
+		return []string{"start_at", "created_at", "samples", "max_lag"}, [][]driver.Value{
+			{breachStart, nil, int64(1), int64(300)},
+		}, nil
+	})
+	h := &Handlers{DB: db}
+	h.SLA.Default.LagWarnSeconds = 60
+
+	rec := httptest.NewRecorder()
+	h.GetSyncHistory(rec, httptest.NewRequest(http.MethodGet,
+		"/api/v1/sync/history?from=2026-01-01T00:00:00Z&to=2026-01-01T01:00:00Z&bucket=30m", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	queries := fake.Queries()
+	if len(queries) != 2 || !strings.Contains(queries[0], "GROUPING SETS") || !strings.Contains(queries[1], "OVER (ORDER BY created_at") {
+		t.Fatalf("queries = %q", queries)
+	}
+	if len(historyArgs) != 4 || historyArgs[2].Value != float64(1800) || historyArgs[3].Value != int64(60) {
+		t.Errorf("history args = %+v, want bucket 1800s and SLA 60", historyArgs)
+	}
+
+	var resp SyncHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Buckets) != 2 || resp.Buckets[1].Samples != 3 || *resp.Buckets[1].Lag.P95 != 300 {
+		t.Errorf("buckets = %s", mustJSON(t, resp.Buckets))
+	}
+	if resp.Overall.Samples != 4 || *resp.Overall.WithinSLAPct != 75 {
+		t.Errorf("overall = %s", mustJSON(t, resp.Overall))
+	}
+	want := &BreachWindow{Start: breachStart, End: to, DurationSeconds: 3000, Samples: 1, MaxLagSeconds: intp(300), Ongoing: true}
+	if !reflect.DeepEqual(resp.LongestBreach, want) {
+		t.Errorf("longest breach = %+v, want %+v", resp.LongestBreach, want)
+	}
+}
+
+func floatp(v float64) *float64 { return &v }
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
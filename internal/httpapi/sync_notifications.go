@@ -0,0 +1,47 @@
+// internal/httpapi/sync_notifications.go
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"mini-poc-02/backend/internal/notify"
+)
+
+type SyncNotificationsResponse struct {
+	Enabled       bool              `json:"enabled"`
+	CurrentStatus string            `json:"current_status,omitempty"` // status terakhir yang dievaluasi notifier
+	Deliveries    []notify.Delivery `json:"deliveries"`
+}
+
+// GetSyncNotifications serves:
+//
+//	GET /api/v1/sync/notifications?limit=50
+//
+// Log pengiriman webhook transisi status sync (terbaru lebih dulu).
+func (h *Handlers) GetSyncNotifications(w http.ResponseWriter, r *http.Request) {
+	if h.Notifier == nil {
+		writeJSON(w, http.StatusOK, SyncNotificationsResponse{Enabled: false, Deliveries: []notify.Delivery{}})
+		return
+	}
+
+	limit := queryInt(r, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	writeJSON(w, http.StatusOK, SyncNotificationsResponse{
+		Enabled:       true,
+		CurrentStatus: h.Notifier.Current(),
+		Deliveries:    h.Notifier.Deliveries(limit),
+	})
+}
+
+// SyncStatusCheck adalah notify.Check yang memakai aturan yang sama dengan GetSyncHealth.
+func (h *Handlers) SyncStatusCheck(ctx context.Context) (string, any, error) {
+	resp, err := h.buildSyncHealth(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.Status, resp, nil
+}
@@ -0,0 +1,65 @@
+// internal/httpapi/sync_reconciliation.go
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"mini-poc-02/backend/internal/logging"
+	"mini-poc-02/backend/internal/reconcile"
+)
+
+type ReconciliationResponse struct {
+	Enabled bool              `json:"enabled"`
+	Running bool              `json:"running"`
+	Report  *reconcile.Report `json:"report"`
+}
+
+// GetReconciliation serves:
+//
+//	GET /api/v1/sync/reconciliation
+//
+// Mengembalikan hasil rekonsiliasi MySQL (source) vs PostgreSQL (ODS) terakhir.
+func (h *Handlers) GetReconciliation(w http.ResponseWriter, r *http.Request) {
+	if h.Reconciler == nil {
+		writeJSON(w, http.StatusOK, ReconciliationResponse{Enabled: false})
+		return
+	}
+
+	rep, running := h.Reconciler.Latest()
+	writeJSON(w, http.StatusOK, ReconciliationResponse{
+		Enabled: true,
+		Running: running,
+		Report:  rep,
+	})
+}
+
+// RunReconciliation serves:
+//
+//	POST /api/v1/sync/reconciliation/run
+//
+// Memicu run baru di background (tidak menunggu selesai, karena bisa lebih lama dari timeout request).
+func (h *Handlers) RunReconciliation(w http.ResponseWriter, r *http.Request) {
+	if h.Reconciler == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "reconciliation is not configured (SOURCE_DB_* env)"})
+		return
+	}
+
+	// tidak memakai context request: run bisa lebih lama dari request, tapi tetap berhenti saat shutdown
+	ctx, cancel := context.WithTimeout(h.baseContext(), 30*time.Minute)
+	logger := logging.FromContext(r.Context())
+	started := h.Reconciler.TryStart(ctx, func(rep *reconcile.Report) {
+		defer cancel()
+		if rep.Status != "ok" {
+			logger.Warn("reconciliation finished", "status", rep.Status)
+		}
+	})
+	if !started {
+		cancel()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": reconcile.ErrAlreadyRunning.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"status": "started"})
+}
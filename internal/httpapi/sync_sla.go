@@ -0,0 +1,214 @@
+// internal/httpapi/sync_sla.go
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SLAPolicy adalah satu set threshold untuk verdict /sync/health.
+// Semua nilai dalam detik; 0 mematikan rule yang bersangkutan.
+type SLAPolicy struct {
+	Name string `json:"name"` // "default" atau nama tabel untuk override
+
+	LagWarnSeconds         int `json:"lag_warn_seconds"`          // lag > ini => warn (target SLA)
+	LagErrorSeconds        int `json:"lag_error_seconds"`         // lag > ini => error
+	MaxSinceSuccessSeconds int `json:"max_since_success_seconds"` // now - last_success_at > ini => error
+	MaxAuditAgeSeconds     int `json:"max_audit_age_seconds"`     // baris sync_audit terbaru lebih tua dari ini => warn
+	FreshnessWarnSeconds   int `json:"freshness_warn_seconds"`    // tabel tanpa perubahan selama ini => warn
+	FreshnessErrorSeconds  int `json:"freshness_error_seconds"`   // tabel tanpa perubahan selama ini => error
+}
+
+// SLAConfig berisi policy default dan override per tabel (sudah di-merge dengan default).
+type SLAConfig struct {
+	Default SLAPolicy
+	Tables  map[string]SLAPolicy
+}
+
+// For mengembalikan policy untuk tabel (mis. "customers" atau "postgres.customers"), atau default.
+func (c SLAConfig) For(table string) SLAPolicy {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	if p, ok := c.Tables[table]; ok {
+		return p
+	}
+	return c.Default
+}
+
+// slaPolicyOverride: bentuk di file; field kosong mewarisi policy default.
+type slaPolicyOverride struct {
+	LagWarnSeconds         *int `json:"lag_warn_seconds"`
+	LagErrorSeconds        *int `json:"lag_error_seconds"`
+	MaxSinceSuccessSeconds *int `json:"max_since_success_seconds"`
+	MaxAuditAgeSeconds     *int `json:"max_audit_age_seconds"`
+	FreshnessWarnSeconds   *int `json:"freshness_warn_seconds"`
+	FreshnessErrorSeconds  *int `json:"freshness_error_seconds"`
+}
+
+func (o slaPolicyOverride) apply(p SLAPolicy) SLAPolicy {
+	set := func(dst *int, v *int) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&p.LagWarnSeconds, o.LagWarnSeconds)
+	set(&p.LagErrorSeconds, o.LagErrorSeconds)
+	set(&p.MaxSinceSuccessSeconds, o.MaxSinceSuccessSeconds)
+	set(&p.MaxAuditAgeSeconds, o.MaxAuditAgeSeconds)
+	set(&p.FreshnessWarnSeconds, o.FreshnessWarnSeconds)
+	set(&p.FreshnessErrorSeconds, o.FreshnessErrorSeconds)
+	return p
+}
+
+// LoadSLAConfig membangun SLAConfig dari policy default (env) dan file JSON opsional:
+//
+//	{
+//	  "default": {"lag_warn_seconds": 10, "lag_error_seconds": 60},
+//	  "tables": {"credit_applications": {"lag_warn_seconds": 5, "freshness_warn_seconds": 600}}
+//	}
+func LoadSLAConfig(path string, def SLAPolicy) (SLAConfig, error) {
+	def.Name = "default"
+	cfg := SLAConfig{Default: def, Tables: map[string]SLAPolicy{}}
+	if path == "" {
+		return cfg, cfg.validate()
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("read SLA policy file: %w", err)
+	}
+	var file struct {
+		Default slaPolicyOverride            `json:"default"`
+		Tables  map[string]slaPolicyOverride `json:"tables"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return cfg, fmt.Errorf("parse SLA policy file %s: %w", path, err)
+	}
+
+	cfg.Default = file.Default.apply(cfg.Default)
+	for table, o := range file.Tables {
+		p := o.apply(cfg.Default)
+		p.Name = table
+		cfg.Tables[table] = p
+	}
+	return cfg, cfg.validate()
+}
+
+func (c SLAConfig) validate() error {
+	policies := []SLAPolicy{c.Default}
+	for _, p := range c.Tables {
+		policies = append(policies, p)
+	}
+	for _, p := range policies {
+		if p.LagWarnSeconds <= 0 {
+			return fmt.Errorf("SLA policy %s: lag_warn_seconds must be > 0", p.Name)
+		}
+		if p.LagErrorSeconds > 0 && p.LagErrorSeconds < p.LagWarnSeconds {
+			return fmt.Errorf("SLA policy %s: lag_error_seconds must be >= lag_warn_seconds", p.Name)
+		}
+		if p.FreshnessErrorSeconds > 0 && p.FreshnessErrorSeconds < p.FreshnessWarnSeconds {
+			return fmt.Errorf("SLA policy %s: freshness_error_seconds must be >= freshness_warn_seconds", p.Name)
+		}
+	}
+	return nil
+}
+
+// SLAVerdict menjelaskan status /sync/health: policy yang dipakai dan rule yang menentukan status.
+type SLAVerdict struct {
+	Policy  SLAPolicy  `json:"policy"`
+	Rule    string     `json:"rule"` // mis. lag_warn, last_error, table_freshness:customers, connector:<name>
+	Message string     `json:"message,omitempty"`
+	Checks  []SLACheck `json:"checks"` // hasil tiap rule terhadap baris sync_audit terbaru
+}
+
+type SLACheck struct {
+	Rule    string `json:"rule"`
+	Status  string `json:"status"` // ok | warn | error
+	Message string `json:"message,omitempty"`
+}
+
+// evaluateAudit menerapkan policy ke baris sync_audit terbaru. Rule dievaluasi semuanya
+// (supaya terlihat di checks); status = yang terburuk, rule = rule pertama dengan status itu.
+func evaluateAudit(resp *SyncHealthResponse, createdAt time.Time, p SLAPolicy, now time.Time) {
+	check := func(rule string, violated bool, status, msg string) {
+		c := SLACheck{Rule: rule, Status: "ok"}
+		if violated {
+			c.Status, c.Message = status, msg
+		}
+		resp.SLA.Checks = append(resp.SLA.Checks, c)
+	}
+
+	hasErr := resp.LastError != nil && *resp.LastError != ""
+	check("last_error", hasErr, "error", "last_error is set")
+
+	if resp.LagSeconds != nil {
+		lag := *resp.LagSeconds
+		if p.LagErrorSeconds > 0 {
+			check("lag_error", lag > p.LagErrorSeconds, "error",
+				fmt.Sprintf("lag %ds exceeds %ds", lag, p.LagErrorSeconds))
+		}
+		check("lag_warn", lag > p.LagWarnSeconds, "warn",
+			fmt.Sprintf("lag %ds exceeds SLA target %ds", lag, p.LagWarnSeconds))
+	}
+
+	if p.MaxSinceSuccessSeconds > 0 {
+		limit := time.Duration(p.MaxSinceSuccessSeconds) * time.Second
+		switch {
+		case resp.LastSuccessAt == nil:
+			check("max_since_success", true, "error", "no last_success_at recorded")
+		default:
+			age := now.Sub(*resp.LastSuccessAt)
+			check("max_since_success", age > limit, "error",
+				fmt.Sprintf("last success %s ago (max %s)", age.Round(time.Second), limit))
+		}
+	}
+
+	if p.MaxAuditAgeSeconds > 0 {
+		limit := time.Duration(p.MaxAuditAgeSeconds) * time.Second
+		age := now.Sub(createdAt)
+		check("max_audit_age", age > limit, "warn",
+			fmt.Sprintf("latest sync_audit row is %s old (max %s)", age.Round(time.Second), limit))
+	}
+
+	resp.Status = "ok"
+	resp.SLA.Rule = "within_sla"
+	for _, c := range resp.SLA.Checks {
+		if statusRank[c.Status] > statusRank[resp.Status] {
+			resp.Status, resp.SLA.Rule, resp.SLA.Message = c.Status, c.Rule, c.Message
+		}
+	}
+}
+
+// explainVerdict mengisi SLA.Rule kalau status akhir ditentukan oleh rule di luar
+// baris sync_audit (freshness tabel, connector, anomali KPI).
+func explainVerdict(resp *SyncHealthResponse, auditStatus string) {
+	if statusRank[resp.Status] <= statusRank[auditStatus] {
+		return
+	}
+
+	tables := append([]TableFreshness(nil), resp.Tables...)
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].Table < tables[j].Table })
+	for _, t := range tables {
+		if t.Status == resp.Status {
+			resp.SLA.Rule, resp.SLA.Message = "table_freshness:"+t.Table, t.Explanation
+			return
+		}
+	}
+	for _, c := range resp.Connectors {
+		if c.Status == resp.Status {
+			resp.SLA.Rule, resp.SLA.Message = "connector:"+c.Name, c.Message
+			return
+		}
+	}
+	if len(resp.Warnings) > 0 {
+		resp.SLA.Rule, resp.SLA.Message = resp.Warnings[0].Code, resp.Warnings[0].Message
+	}
+}
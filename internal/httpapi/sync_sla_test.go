@@ -0,0 +1,198 @@
+// internal/httpapi/sync_sla_test.go
+package httpapi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSLAConfig(t *testing.T) {
+	def := SLAPolicy{LagWarnSeconds: 10, LagErrorSeconds: 60, MaxSinceSuccessSeconds: 900}
+
+	tests := []struct {
+		name       string
+		file       string // "" = tanpa file
+		wantErr    string
+		wantDef    SLAPolicy
+		wantTables map[string]SLAPolicy
+	}{
+		{
+			name:       "env defaults only",
+			wantDef:    SLAPolicy{Name: "default", LagWarnSeconds: 10, LagErrorSeconds: 60, MaxSinceSuccessSeconds: 900},
+			wantTables: map[string]SLAPolicy{},
+		},
+		{
+			name: "file default overrides env, tables inherit merged default",
+			file: `{
+				"default": {"lag_warn_seconds": 20, "max_audit_age_seconds": 300},
+				"tables": {
+					"credit_applications": {"lag_warn_seconds": 5, "freshness_warn_seconds": 600},
+					"customers": {"max_since_success_seconds": 0}
+				}
+			}`,
+			wantDef: SLAPolicy{Name: "default", LagWarnSeconds: 20, LagErrorSeconds: 60, MaxSinceSuccessSeconds: 900, MaxAuditAgeSeconds: 300},
+			wantTables: map[string]SLAPolicy{
+				"credit_applications": {Name: "credit_applications", LagWarnSeconds: 5, LagErrorSeconds: 60, MaxSinceSuccessSeconds: 900, MaxAuditAgeSeconds: 300, FreshnessWarnSeconds: 600},
+				// 0 eksplisit mematikan rule, bukan mewarisi default
+				"customers": {Name: "customers", LagWarnSeconds: 20, LagErrorSeconds: 60, MaxAuditAgeSeconds: 300},
+			},
+		},
+		{name: "unknown field", file: `{"default": {"lag_warn": 5}}`, wantErr: "unknown field"},
+		{name: "unknown top-level field", file: `{"overrides": {}}`, wantErr: "unknown field"},
+		{name: "lag warn disabled", file: `{"default": {"lag_warn_seconds": 0}}`, wantErr: "default: lag_warn_seconds must be > 0"},
+		{name: "table error below warn", file: `{"tables": {"customers": {"lag_warn_seconds": 120}}}`, wantErr: "customers: lag_error_seconds must be >= lag_warn_seconds"},
+		{
+			name:    "freshness error below warn",
+			file:    `{"default": {"freshness_warn_seconds": 600, "freshness_error_seconds": 60}}`,
+			wantErr: "freshness_error_seconds must be >= freshness_warn_seconds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "sla.json")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := LoadSLAConfig(path, def)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Default != tt.wantDef {
+				t.Errorf("default = %+v, want %+v", cfg.Default, tt.wantDef)
+			}
+			if !reflect.DeepEqual(cfg.Tables, tt.wantTables) {
+				t.Errorf("tables = %+v, want %+v", cfg.Tables, tt.wantTables)
+			}
+		})
+	}
+
+	if _, err := LoadSLAConfig(filepath.Join(t.TempDir(), "missing.json"), def); err == nil {
+		t.Error("missing file accepted")
+	}
+}
+
+func TestSLAConfigFor(t *testing.T) {
+	cfg := SLAConfig{
+		Default: SLAPolicy{Name: "default"},
+		Tables:  map[string]SLAPolicy{"customers": {Name: "customers"}},
+	}
+	for table, want := range map[string]string{
+		"customers":          "customers",
+		"postgres.customers": "customers",
+		"vehicles":           "default",
+		"":                   "default",
+	} {
+		if got := cfg.For(table).Name; got != want {
+			t.Errorf("For(%q) = %q, want %q", table, got, want)
+		}
+	}
+}
+
+func TestEvaluateAudit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := SLAPolicy{LagWarnSeconds: 10, LagErrorSeconds: 60, MaxSinceSuccessSeconds: 600, MaxAuditAgeSeconds: 300}
+	ago := func(d time.Duration) *time.Time { v := now.Add(-d); return &v }
+	errMsg := "connector failed"
+
+	tests := []struct {
+		name        string
+		policy      SLAPolicy
+		resp        SyncHealthResponse
+		createdAt   time.Time
+		wantStatus  string
+		wantRule    string
+		wantChecks  []string // rule=status, urut evaluasi
+		wantMessage string   // substring
+	}{
+		{
+			name:       "within SLA",
+			policy:     policy,
+			resp:       SyncHealthResponse{LagSeconds: intp(3), LastSuccessAt: ago(time.Minute)},
+			createdAt:  now.Add(-time.Minute),
+			wantStatus: "ok", wantRule: "within_sla",
+			wantChecks: []string{"last_error=ok", "lag_error=ok", "lag_warn=ok", "max_since_success=ok", "max_audit_age=ok"},
+		},
+		{
+			name:       "lag over target only warns",
+			policy:     policy,
+			resp:       SyncHealthResponse{LagSeconds: intp(11), LastSuccessAt: ago(time.Minute)},
+			createdAt:  now,
+			wantStatus: "warn", wantRule: "lag_warn", wantMessage: "lag 11s exceeds SLA target 10s",
+			wantChecks: []string{"last_error=ok", "lag_error=ok", "lag_warn=warn", "max_since_success=ok", "max_audit_age=ok"},
+		},
+		{
+			name:       "error rule beats earlier warn and first error wins",
+			policy:     policy,
+			resp:       SyncHealthResponse{LagSeconds: intp(61), LastSuccessAt: ago(time.Hour), LastError: &errMsg},
+			createdAt:  now.Add(-time.Hour),
+			wantStatus: "error", wantRule: "last_error",
+			wantChecks: []string{"last_error=error", "lag_error=error", "lag_warn=warn", "max_since_success=error", "max_audit_age=warn"},
+		},
+		{
+			name:       "lag error reported before lag warn",
+			policy:     policy,
+			resp:       SyncHealthResponse{LagSeconds: intp(61), LastSuccessAt: ago(time.Minute)},
+			createdAt:  now,
+			wantStatus: "error", wantRule: "lag_error", wantMessage: "lag 61s exceeds 60s",
+			wantChecks: []string{"last_error=ok", "lag_error=error", "lag_warn=warn", "max_since_success=ok", "max_audit_age=ok"},
+		},
+		{
+			name:       "stale audit row warns even with tiny lag",
+			policy:     policy,
+			resp:       SyncHealthResponse{LagSeconds: intp(1), LastSuccessAt: ago(time.Minute)},
+			createdAt:  now.Add(-10 * time.Minute),
+			wantStatus: "warn", wantRule: "max_audit_age", wantMessage: "10m0s old (max 5m0s)",
+			wantChecks: []string{"last_error=ok", "lag_error=ok", "lag_warn=ok", "max_since_success=ok", "max_audit_age=warn"},
+		},
+		{
+			name:       "never succeeded",
+			policy:     policy,
+			resp:       SyncHealthResponse{},
+			createdAt:  now,
+			wantStatus: "error", wantRule: "max_since_success", wantMessage: "no last_success_at",
+			wantChecks: []string{"last_error=ok", "max_since_success=error", "max_audit_age=ok"},
+		},
+		{
+			name:       "empty last_error is not an error",
+			policy:     SLAPolicy{LagWarnSeconds: 10},
+			resp:       SyncHealthResponse{LastError: new(string), LagSeconds: intp(5)},
+			createdAt:  now.Add(-24 * time.Hour),
+			wantStatus: "ok", wantRule: "within_sla",
+			wantChecks: []string{"last_error=ok", "lag_warn=ok"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.resp
+			evaluateAudit(&resp, tt.createdAt, tt.policy, now)
+
+			if resp.Status != tt.wantStatus || resp.SLA.Rule != tt.wantRule {
+				t.Errorf("status/rule = %s/%s, want %s/%s", resp.Status, resp.SLA.Rule, tt.wantStatus, tt.wantRule)
+			}
+			if !strings.Contains(resp.SLA.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want containing %q", resp.SLA.Message, tt.wantMessage)
+			}
+			checks := make([]string, 0, len(resp.SLA.Checks))
+			for _, c := range resp.SLA.Checks {
+				checks = append(checks, c.Rule+"="+c.Status)
+			}
+			if !reflect.DeepEqual(checks, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", checks, tt.wantChecks)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// internal/kafkaconnect/client.go
+package kafkaconnect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"mini-poc-02/backend/internal/tracing"
+)
+
+// State values yang dikembalikan Kafka Connect REST API.
+const (
+	StateRunning    = "RUNNING"
+	StatePaused     = "PAUSED"
+	StateFailed     = "FAILED"
+	StateUnassigned = "UNASSIGNED"
+	StateRestarting = "RESTARTING"
+)
+
+var ErrConnectorNotFound = errors.New("connector not found")
+
+// Client adalah client minimal untuk Kafka Connect REST API (mis. http://localhost:8083).
+// HTTPClient bisa diganti (mis. ke httptest.Server) supaya bisa dites tanpa cluster asli.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type WorkerState struct {
+	State    string `json:"state"`
+	WorkerID string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
+}
+
+type TaskState struct {
+	ID       int    `json:"id"`
+	State    string `json:"state"`
+	WorkerID string `json:"worker_id"`
+	Trace    string `json:"trace,omitempty"`
+}
+
+// ConnectorStatus adalah response GET /connectors/{name}/status.
+type ConnectorStatus struct {
+	Name      string      `json:"name"`
+	Connector WorkerState `json:"connector"`
+	Tasks     []TaskState `json:"tasks"`
+	Type      string      `json:"type"` // source | sink
+}
+
+// ConnectorStatus membaca status connector beserta state tiap task-nya.
+func (c *Client) ConnectorStatus(ctx context.Context, name string) (ConnectorStatus, error) {
+	var st ConnectorStatus
+
+	u := c.BaseURL + "/connectors/" + url.PathEscape(name) + "/status"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return st, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// lanjutkan trace request /sync/health (kalau ada) ke Kafka Connect
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return st, fmt.Errorf("%w: %s", ErrConnectorNotFound, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return st, fmt.Errorf("kafka connect %s: unexpected status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return st, fmt.Errorf("decode connector status %s: %w", name, err)
+	}
+	return st, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,97 @@
+// internal/kafkaconnect/client_test.go
+package kafkaconnect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeConnect meniru endpoint GET /connectors/{name}/status Kafka Connect.
+func fakeConnect(t *testing.T, statuses map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.EscapedPath(), "/connectors/"), "/status")
+		if r.Method != http.MethodGet || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body, ok := statuses[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":404,"message":"No status found for connector ` + name + `"}`))
+			return
+		}
+		if body == "" {
+			http.Error(w, "worker is rebalancing", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConnectorStatus(t *testing.T) {
+	srv := fakeConnect(t, map[string]string{
+		"mks-source": `{"name":"mks-source","type":"source",
+			"connector":{"state":"RUNNING","worker_id":"10.0.0.5:8083"},
+			"tasks":[{"id":0,"state":"FAILED","worker_id":"10.0.0.5:8083","trace":"org.apache.kafka.connect.errors.ConnectException: boom"}]}`,
+		"mks%2Fsink": `{"name":"mks/sink","type":"sink","connector":{"state":"PAUSED","worker_id":"w"},"tasks":[]}`,
+		"busy":       "",
+		"garbage":    `{"name":`,
+	})
+	c := NewClient(srv.URL+"/", time.Second)
+	c.HTTPClient = srv.Client()
+	ctx := context.Background()
+
+	st, err := c.ConnectorStatus(ctx, "mks-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Type != "source" || st.Connector.State != StateRunning || st.Connector.WorkerID != "10.0.0.5:8083" {
+		t.Errorf("status = %+v", st)
+	}
+	if len(st.Tasks) != 1 || st.Tasks[0].State != StateFailed || !strings.Contains(st.Tasks[0].Trace, "ConnectException") {
+		t.Errorf("tasks = %+v", st.Tasks)
+	}
+
+	// nama connector di-escape di path
+	st, err = c.ConnectorStatus(ctx, "mks/sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Connector.State != StatePaused || st.Type != "sink" {
+		t.Errorf("status = %+v", st)
+	}
+
+	if _, err := c.ConnectorStatus(ctx, "missing"); !errors.Is(err, ErrConnectorNotFound) {
+		t.Errorf("missing connector: error = %v, want %v", err, ErrConnectorNotFound)
+	}
+	if _, err := c.ConnectorStatus(ctx, "busy"); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("non-200: error = %v, want status 409", err)
+	}
+	if _, err := c.ConnectorStatus(ctx, "garbage"); err == nil {
+		t.Error("invalid JSON: want error")
+	}
+}
+
+func TestConnectorStatusTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, 50*time.Millisecond)
+	if _, err := c.ConnectorStatus(context.Background(), "slow"); err == nil {
+		t.Fatal("want timeout error")
+	}
+}
@@ -0,0 +1,107 @@
+// internal/logging/access.go
+package logging
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader: request ID (dari middleware.RequestID) dikembalikan ke klien di header ini.
+const RequestIDHeader = "X-Request-ID"
+
+// ErrorRecorder diimplementasikan ResponseWriter access log: handler yang menulis 5xx
+// menitipkan error-nya supaya tercatat di baris access log request yang sama.
+type ErrorRecorder interface {
+	RecordError(msg string, err error)
+}
+
+// RecordError menitipkan error ke access log lewat w (menelusuri Unwrap). No-op kalau
+// access log tidak aktif.
+func RecordError(w http.ResponseWriter, msg string, err error) {
+	for w != nil {
+		if rec, ok := w.(ErrorRecorder); ok {
+			rec.RecordError(msg, err)
+			return
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+type accessWriter struct {
+	middleware.WrapResponseWriter
+	errMsg string
+	err    error
+}
+
+func (a *accessWriter) RecordError(msg string, err error) {
+	a.errMsg, a.err = msg, err
+}
+
+func (a *accessWriter) Unwrap() http.ResponseWriter {
+	return a.WrapResponseWriter.Unwrap()
+}
+
+// AccessLog mencatat satu baris per request: method, pola route chi, status, latency, bytes,
+// request_id. Logger request (dengan request_id) disimpan di context untuk log handler.
+// Harus dipasang setelah middleware.RequestID dan middleware IP klien (realip).
+func AccessLog(l *slog.Logger, enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			reqID := middleware.GetReqID(r.Context())
+			if reqID != "" {
+				w.Header().Set(RequestIDHeader, reqID)
+			}
+			reqLog := l.With("request_id", reqID)
+
+			aw := &accessWriter{WrapResponseWriter: middleware.NewWrapResponseWriter(w, r.ProtoMajor)}
+			next.ServeHTTP(aw, r.WithContext(WithLogger(r.Context(), reqLog)))
+
+			if !enabled && aw.err == nil {
+				return
+			}
+			status := aw.Status()
+			if status == 0 {
+				status = http.StatusOK // handler tidak menulis apa pun
+			}
+			route := ""
+			if rc := chi.RouteContext(r.Context()); rc != nil {
+				route = rc.RoutePattern()
+			}
+
+			attrs := []any{
+				"method", r.Method,
+				"route", route,
+				"path", r.URL.Path,
+				"status", status,
+				"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"bytes", aw.BytesWritten(),
+				"remote_ip", remoteIP(r),
+			}
+			level := slog.LevelInfo
+			if status >= 500 {
+				level = slog.LevelError
+			}
+			if aw.err != nil {
+				attrs = append(attrs, "error", aw.errMsg, "details", aw.err.Error())
+			}
+			reqLog.Log(r.Context(), level, "http request", attrs...)
+		})
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,121 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Bucket latency (detik): HTTP mengikuti default Prometheus, query DB lebih halus di bawah 10ms.
+var (
+	DefaultBuckets = prometheus.DefBuckets
+	QueryBuckets   = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+)
+
+// Metrics: metric aplikasi (HTTP & query DB) di atas registry Prometheus sendiri
+// (bukan prometheus.DefaultRegisterer), ditambah metric runtime Go dan proses.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, chi route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, chi route pattern and status.",
+			Buckets: DefaultBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of named SQL queries issued by handlers.",
+			Buckets: QueryBuckets,
+		}, []string{"query", "outcome"}),
+	}
+	m.Registry.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Register menambah collector (mis. DBStats, sync lag). Nama metric yang bentrok = error.
+func (m *Metrics) Register(c prometheus.Collector) error {
+	return m.Registry.Register(c)
+}
+
+// Middleware mencatat counter & latency per request. Label route memakai pola chi
+// (mis. /api/v1/customers/{customer_id}/profile), bukan path, supaya cardinality terbatas;
+// request yang tidak cocok route mana pun dicatat sebagai "unmatched".
+// Metrics nil = tidak ada yang dicatat.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := "unmatched"
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+		m.httpRequests.With(labels).Inc()
+		m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveQuery mencatat durasi satu query bernama. sql.ErrNoRows dihitung "ok"
+// (hasil kosong, bukan kegagalan).
+func (m *Metrics) ObserveQuery(name string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "ok"
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		outcome = "error"
+	}
+	m.queryDuration.WithLabelValues(name, outcome).Observe(d.Seconds())
+}
+
+// Handler melayani GET /metrics. Collector yang gagal di-log dan dilewati, metric lain
+// tetap dikirim (satu query sync_audit yang timeout tidak boleh menghapus semua metric).
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{
+		ErrorLog:      slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		ErrorHandling: promhttp.ContinueOnError,
+		Registry:      m.Registry, // promhttp_metric_handler_errors_total
+	})
+}
+
+// DBStats: sql.DBStats pool sebagai metric go_sql_* dengan label db_name.
+func DBStats(dbName string, db *sql.DB) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db, dbName)
+}
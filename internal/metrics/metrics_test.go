@@ -0,0 +1,140 @@
+// internal/metrics/metrics_test.go
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape status = %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func wantLines(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, l := range lines {
+		if !strings.Contains(body, "\n"+l+"\n") {
+			t.Errorf("missing line %q", l)
+		}
+	}
+}
+
+func TestQueryHistogramBucketsAreCumulative(t *testing.T) {
+	m := New()
+	for _, d := range []time.Duration{
+		500 * time.Microsecond,
+		3 * time.Millisecond,
+		3 * time.Millisecond,
+		200 * time.Millisecond,
+		10 * time.Second, // di atas bucket terbesar: hanya masuk +Inf
+	} {
+		m.ObserveQuery("getCustomer", d, nil)
+	}
+	m.ObserveQuery("getCustomer", time.Millisecond, sql.ErrNoRows)
+	m.ObserveQuery("getCustomer", time.Millisecond, errors.New("connection reset"))
+
+	body := scrape(t, m)
+	wantLines(t, body,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="0.001"} 2`,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="0.0025"} 2`,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="0.005"} 4`,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="0.1"} 4`,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="0.25"} 5`,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="5"} 5`,
+		`db_query_duration_seconds_bucket{outcome="ok",query="getCustomer",le="+Inf"} 6`,
+		`db_query_duration_seconds_count{outcome="ok",query="getCustomer"} 6`,
+		`db_query_duration_seconds_count{outcome="error",query="getCustomer"} 1`,
+	)
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	m := New()
+	m.ObserveQuery("we\"ird\\name\nx", time.Millisecond, nil)
+
+	body := scrape(t, m)
+	wantLines(t, body, `db_query_duration_seconds_count{outcome="ok",query="we\"ird\\name\nx"} 1`)
+}
+
+func TestMiddlewareLabelsByRoutePattern(t *testing.T) {
+	m := New()
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/api/v1/customers/{customer_id}/profile", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok")) // tanpa WriteHeader = 200
+	})
+
+	for _, path := range []string{"/api/v1/customers/C1/profile", "/api/v1/customers/C2/profile", "/api/v1/health", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, m)
+	wantLines(t, body,
+		`http_requests_total{method="GET",route="/api/v1/customers/{customer_id}/profile",status="404"} 2`,
+		`http_requests_total{method="GET",route="/api/v1/health",status="200"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	)
+	if strings.Contains(body, "C1") {
+		t.Error("raw path leaked into labels")
+	}
+}
+
+func TestDBStatsAndRuntimeCollectors(t *testing.T) {
+	db := sql.OpenDB(nopConnector{}) // tidak pernah membuka koneksi: Stats saja
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+
+	m := New()
+	if err := m.Register(DBStats("ods", db)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register(DBStats("ods", db)); err == nil {
+		t.Error("registering the same collector twice: want error")
+	}
+
+	body := scrape(t, m)
+	wantLines(t, body, `go_sql_max_open_connections{db_name="ods"} 7`)
+	for _, name := range []string{"go_goroutines", "go_sql_wait_duration_seconds_total"} {
+		if !strings.Contains(body, "\n"+name) {
+			t.Errorf("missing metric %s", name)
+		}
+	}
+}
+
+type nopConnector struct{}
+
+func (nopConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("no database")
+}
+func (nopConnector) Driver() driver.Driver { return nil }
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveQuery("x", time.Millisecond, nil)
+	rec := httptest.NewRecorder()
+	m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
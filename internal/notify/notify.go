@@ -0,0 +1,324 @@
+// internal/notify/notify.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event yang dikirim ke webhook.
+const (
+	EventDegraded  = "sync.degraded"  // status memburuk: ok->warn, warn->error, ok->error
+	EventRecovered = "sync.recovered" // status membaik: error->warn, error->ok, warn->ok
+)
+
+// Status delivery di log.
+const (
+	DeliveryPending    = "pending"
+	DeliveryDelivered  = "delivered"
+	DeliveryFailed     = "failed"
+	DeliverySuppressed = "suppressed" // transisi identik baru saja dikirim dalam dedup window
+)
+
+// Config mengatur notifier webhook.
+type Config struct {
+	URLs        []string
+	Secret      string        // kunci HMAC-SHA256 untuk header X-Webhook-Signature
+	Interval    time.Duration // jarak antar evaluasi status
+	MaxAttempts int           // percobaan kirim per URL
+	Backoff     time.Duration // jeda awal antar percobaan, dikali 2 tiap gagal
+	Timeout     time.Duration // timeout satu request HTTP
+	DedupWindow time.Duration // transisi from->to yang sama tidak dikirim ulang dalam window ini
+	LogSize     int           // jumlah delivery terakhir yang disimpan
+}
+
+// Check mengevaluasi status sync saat ini. detail ikut dikirim di payload (mis. SyncHealthResponse).
+type Check func(ctx context.Context) (status string, detail any, err error)
+
+// Payload adalah body JSON yang di-POST ke webhook.
+type Payload struct {
+	ID     string    `json:"id"` // sama untuk semua URL dan retry; pakai untuk idempotensi di sisi penerima
+	Event  string    `json:"event"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	Detail any       `json:"detail,omitempty"`
+}
+
+// Delivery adalah satu entri log pengiriman (per notifikasi per URL).
+type Delivery struct {
+	NotificationID string     `json:"notification_id"`
+	Event          string     `json:"event"`
+	From           string     `json:"from"`
+	To             string     `json:"to"`
+	URL            string     `json:"url,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseCode   int        `json:"response_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Notifier mengevaluasi status sync secara periodik dan mengirim webhook saat status berubah.
+type Notifier struct {
+	cfg    Config
+	check  Check
+	client *http.Client
+
+	mu         sync.Mutex
+	current    string
+	lastSent   string // transisi terakhir yang dikirim, "from->to"
+	lastSentAt time.Time
+	log        []*Delivery
+}
+
+func New(check Check, cfg Config) (*Notifier, error) {
+	if len(cfg.URLs) == 0 {
+		return nil, errors.New("notify: no webhook URLs configured")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 2 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.DedupWindow < 0 {
+		cfg.DedupWindow = 0
+	}
+	if cfg.LogSize <= 0 {
+		cfg.LogSize = 200
+	}
+	return &Notifier{
+		cfg:    cfg,
+		check:  check,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Current mengembalikan status terakhir yang dievaluasi ("" kalau belum pernah).
+func (n *Notifier) Current() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.current
+}
+
+// Deliveries mengembalikan log pengiriman, terbaru lebih dulu.
+func (n *Notifier) Deliveries(limit int) []Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	out := make([]Delivery, 0, len(n.log))
+	for i := len(n.log) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		out = append(out, *n.log[i])
+	}
+	return out
+}
+
+// Start mengevaluasi status tiap interval sampai ctx selesai.
+func (n *Notifier) Start(ctx context.Context, logger *slog.Logger) {
+	t := time.NewTicker(n.cfg.Interval)
+	defer t.Stop()
+	for {
+		if err := n.Evaluate(ctx, logger); err != nil && ctx.Err() == nil {
+			logger.Error("notify: evaluate sync status failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Evaluate menjalankan satu evaluasi dan memicu pengiriman kalau ada transisi.
+// Status awal dianggap "ok", jadi backend yang start saat sync sudah bermasalah tetap mengirim notifikasi.
+func (n *Notifier) Evaluate(ctx context.Context, logger *slog.Logger) error {
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	status, detail, err := n.check(cctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	prev := n.current
+	if prev == "" {
+		prev = "ok"
+	}
+	n.current = status
+	n.mu.Unlock()
+
+	event := transitionEvent(prev, status)
+	if event == "" {
+		return nil
+	}
+
+	p := Payload{ID: newID(), Event: event, From: prev, To: status, At: time.Now().UTC(), Detail: detail}
+	if n.suppressed(p) {
+		n.append(&Delivery{
+			NotificationID: p.ID, Event: p.Event, From: p.From, To: p.To,
+			Status: DeliverySuppressed, CreatedAt: p.At,
+		})
+		return nil
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	for _, url := range n.cfg.URLs {
+		d := &Delivery{
+			NotificationID: p.ID, Event: p.Event, From: p.From, To: p.To,
+			URL: url, Status: DeliveryPending, CreatedAt: p.At,
+		}
+		n.append(d)
+		// kirim di background supaya retry tidak menunda evaluasi berikutnya
+		go n.deliver(context.WithoutCancel(ctx), d, body, logger)
+	}
+	return nil
+}
+
+// suppressed menandai transisi yang identik dengan transisi terakhir yang dikirim dalam
+// dedup window. Hanya transisi berurutan yang dibandingkan: ok->error setelah error->ok
+// (recovery) selalu dikirim, supaya penerima tidak tertinggal di status yang salah.
+func (n *Notifier) suppressed(p Payload) bool {
+	key := p.From + "->" + p.To
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if key == n.lastSent && n.cfg.DedupWindow > 0 && p.At.Sub(n.lastSentAt) < n.cfg.DedupWindow {
+		return true
+	}
+	n.lastSent, n.lastSentAt = key, p.At
+	return false
+}
+
+func (n *Notifier) append(d *Delivery) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.log = append(n.log, d)
+	if len(n.log) > n.cfg.LogSize {
+		n.log = n.log[len(n.log)-n.cfg.LogSize:]
+	}
+}
+
+func (n *Notifier) deliver(ctx context.Context, d *Delivery, body []byte, logger *slog.Logger) {
+	backoff := n.cfg.Backoff
+	for attempt := 1; attempt <= n.cfg.MaxAttempts; attempt++ {
+		code, err := n.post(ctx, d.URL, d.NotificationID, body)
+
+		n.mu.Lock()
+		d.Attempts = attempt
+		d.ResponseCode = code
+		if err == nil {
+			now := time.Now().UTC()
+			d.Status = DeliveryDelivered
+			d.DeliveredAt = &now
+			d.LastError = ""
+			n.mu.Unlock()
+			return
+		}
+		d.LastError = err.Error()
+		// 4xx selain 408/429 tidak akan berhasil dengan retry
+		permanent := code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+		if permanent || attempt == n.cfg.MaxAttempts {
+			d.Status = DeliveryFailed
+		}
+		n.mu.Unlock()
+
+		if d.Status == DeliveryFailed {
+			logger.Error("notify: webhook delivery failed", "url", d.URL, "notification_id", d.NotificationID, "attempts", attempt, "error", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post mengirim satu request. Signature: hex(HMAC-SHA256(secret, timestamp + "." + body)).
+func (n *Notifier) post(ctx context.Context, url, id string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", id)
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	if n.cfg.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+Sign(n.cfg.Secret, ts, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign menghitung signature webhook; penerima memverifikasi dengan cara yang sama.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func transitionEvent(from, to string) string {
+	switch {
+	case rank(to) > rank(from):
+		return EventDegraded
+	case rank(to) < rank(from):
+		return EventRecovered
+	default:
+		return ""
+	}
+}
+
+func rank(status string) int {
+	switch status {
+	case "error":
+		return 2
+	case "warn":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
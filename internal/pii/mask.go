@@ -0,0 +1,169 @@
+// internal/pii/mask.go
+package pii
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"mini-poc-02/backend/internal/auth"
+)
+
+// Maskable diimplementasikan tipe yang nama field PII-nya baru diketahui saat runtime
+// (mis. diff histori: {"field": "nik", "old_value": ..., "new_value": ...}).
+type Maskable interface {
+	MaskPII(mask func(field, value string) string)
+}
+
+// Masker menerapkan Policy berdasarkan role principal di context. Masker nil = masking mati.
+type Masker struct {
+	Policy Policy
+}
+
+func NewMasker(p Policy) (*Masker, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &Masker{Policy: p}, nil
+}
+
+// Apply memasking in-place semua field string / *string yang nama JSON-nya punya rule,
+// menelusuri struct, pointer, dan slice. v harus pointer supaya field bisa diubah.
+func (m *Masker) Apply(ctx context.Context, v any) {
+	if m == nil {
+		return
+	}
+	walk(reflect.ValueOf(v), m.maskFunc(auth.PrincipalFrom(ctx)))
+}
+
+// Mask memasking satu nilai untuk principal di context.
+func (m *Masker) Mask(ctx context.Context, field, value string) string {
+	if m == nil {
+		return value
+	}
+	return m.maskFunc(auth.PrincipalFrom(ctx))(field, value)
+}
+
+// MaskedFields mengembalikan field yang dimasking untuk principal di context (urut nama).
+func (m *Masker) MaskedFields(ctx context.Context) []string {
+	if m == nil {
+		return nil
+	}
+	visible := m.visible(auth.PrincipalFrom(ctx))
+	out := []string{}
+	for f, r := range m.Policy.Fields {
+		if r.Strategy != StrategyNone && !visible(f) {
+			out = append(out, f)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (m *Masker) maskFunc(p *auth.Principal) func(field, value string) string {
+	visible := m.visible(p)
+	return func(field, value string) string {
+		r, ok := m.Policy.Fields[field]
+		if !ok || visible(field) {
+			return value
+		}
+		return MaskValue(r, value)
+	}
+}
+
+// visible: tanpa principal (auth mati) semua field dimasking.
+func (m *Masker) visible(p *auth.Principal) func(field string) bool {
+	allowed := map[string]bool{}
+	if p != nil {
+		for _, role := range p.Roles {
+			for _, f := range m.Policy.Unmasked[role] {
+				allowed[f] = true
+			}
+		}
+	}
+	return func(field string) bool { return allowed["*"] || allowed[field] }
+}
+
+// MaskValue menerapkan satu rule ke nilai. Nilai kosong dibiarkan kosong.
+func MaskValue(r Rule, value string) string {
+	if value == "" {
+		return value
+	}
+	switch r.Strategy {
+	case StrategyNone:
+		return value
+	case StrategyPartial:
+		return partial(value, r.KeepPrefix, r.KeepSuffix)
+	case StrategyEmail:
+		at := strings.LastIndex(value, "@")
+		if at <= 0 {
+			return partial(value, 0, 0)
+		}
+		first := []rune(value[:at])[0]
+		return string(first) + "***" + value[at:] // panjang local part tidak ikut terlihat
+	default:
+		return "****"
+	}
+}
+
+// partial: nilai yang terlalu pendek untuk disisakan prefix+suffix dimasking seluruhnya.
+func partial(value string, prefix, suffix int) string {
+	rs := []rune(value)
+	if len(rs) <= prefix+suffix {
+		return strings.Repeat("*", len(rs))
+	}
+	return string(rs[:prefix]) + strings.Repeat("*", len(rs)-prefix-suffix) + string(rs[len(rs)-suffix:])
+}
+
+func walk(v reflect.Value, mask func(field, value string) string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), mask)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return // []byte / json.RawMessage
+		}
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), mask)
+		}
+	case reflect.Struct:
+		if v.CanAddr() {
+			if mk, ok := v.Addr().Interface().(Maskable); ok {
+				mk.MaskPII(mask)
+				return
+			}
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			switch {
+			case fv.Kind() == reflect.String && fv.CanSet():
+				fv.SetString(mask(jsonName(sf), fv.String()))
+			case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.String:
+				if !fv.IsNil() && fv.CanSet() {
+					// alokasi baru supaya nilai asli yang ditunjuk pointer tidak ikut berubah
+					s := reflect.New(fv.Type().Elem()).Elem()
+					s.SetString(mask(jsonName(sf), fv.Elem().String()))
+					fv.Set(s.Addr())
+				}
+			default:
+				walk(fv, mask)
+			}
+		}
+	}
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
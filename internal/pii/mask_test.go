@@ -0,0 +1,218 @@
+// internal/pii/mask_test.go
+package pii
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"mini-poc-02/backend/internal/auth"
+)
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		value string
+		want  string
+	}{
+		{name: "partial nik", rule: Rule{Strategy: StrategyPartial, KeepPrefix: 4, KeepSuffix: 4}, value: "3201123456780001", want: "3201********0001"},
+		{name: "partial phone", rule: Rule{Strategy: StrategyPartial, KeepPrefix: 6, KeepSuffix: 3}, value: "+628123456789", want: "+62812****789"},
+		{name: "partial prefix only", rule: Rule{Strategy: StrategyPartial, KeepPrefix: 1}, value: "Budi", want: "B***"},
+		{name: "partial nothing kept", rule: Rule{Strategy: StrategyPartial}, value: "abc", want: "***"},
+		{name: "email", rule: Rule{Strategy: StrategyEmail}, value: "budi.santoso@example.com", want: "b***@example.com"},
+		{name: "email last at", rule: Rule{Strategy: StrategyEmail}, value: `"a@b"@example.com`, want: `"***@example.com`},
+		{name: "email without local part", rule: Rule{Strategy: StrategyEmail}, value: "@example.com", want: "************"},
+		{name: "email without at", rule: Rule{Strategy: StrategyEmail}, value: "budi", want: "****"},
+		{name: "redact", rule: Rule{Strategy: StrategyRedact}, value: "Jl. Merdeka No. 1", want: "****"},
+		{name: "unknown strategy redacts", rule: Rule{Strategy: "rot13"}, value: "secret", want: "****"},
+		{name: "none", rule: Rule{Strategy: StrategyNone}, value: "Jl. Merdeka No. 1", want: "Jl. Merdeka No. 1"},
+		{name: "empty stays empty", rule: Rule{Strategy: StrategyRedact}, value: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskValue(tt.rule, tt.value); got != tt.want {
+				t.Errorf("MaskValue(%+v, %q) = %q, want %q", tt.rule, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartial(t *testing.T) {
+	tests := []struct {
+		value          string
+		prefix, suffix int
+		want           string
+	}{
+		// terlalu pendek untuk prefix+suffix: dimasking seluruhnya
+		{value: "12345678", prefix: 4, suffix: 4, want: "********"},
+		{value: "1234567", prefix: 4, suffix: 4, want: "*******"},
+		{value: "x", prefix: 1, suffix: 0, want: "*"},
+		{value: "123456789", prefix: 4, suffix: 4, want: "1234*6789"},
+		{value: "abcdef", prefix: 0, suffix: 2, want: "****ef"},
+		// multibyte: dihitung per rune, bukan per byte
+		{value: "Śląsk", prefix: 1, suffix: 1, want: "Ś***k"},
+		{value: "山田太郎", prefix: 1, suffix: 0, want: "山***"},
+		{value: "日本", prefix: 1, suffix: 1, want: "**"},
+	}
+	for _, tt := range tests {
+		if got := partial(tt.value, tt.prefix, tt.suffix); got != tt.want {
+			t.Errorf("partial(%q, %d, %d) = %q, want %q", tt.value, tt.prefix, tt.suffix, got, tt.want)
+		}
+	}
+
+	if got := MaskValue(Rule{Strategy: StrategyEmail}, "Ésa@example.com"); got != "É***@example.com" {
+		t.Errorf("multibyte email = %q", got)
+	}
+}
+
+type maskContact struct {
+	Name  string  `json:"emergency_contact_name"`
+	Phone *string `json:"emergency_contact_phone,omitempty"`
+}
+
+type maskCustomer struct {
+	ID       string         `json:"customer_id"`
+	NIK      string         `json:"nik"`
+	Email    *string        `json:"email"`
+	Address  string         `json:"-"` // tanpa nama JSON: rule dicari dengan nama field Go
+	Contacts []maskContact  `json:"contacts"`
+	Primary  *maskContact   `json:"primary"`
+	Extra    any            `json:"extra"`
+	Raw      []byte         `json:"raw"`
+	History  []maskDiff     `json:"history"`
+	secret   string         // tidak diekspor: tidak disentuh
+	Nested   [1]maskContact `json:"nested"`
+}
+
+// maskDiff meniru diff histori: nama field PII ada di data, bukan di tag.
+type maskDiff struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+}
+
+func (d *maskDiff) MaskPII(mask func(field, value string) string) {
+	d.OldValue = mask(d.Field, d.OldValue)
+}
+
+func strp(s string) *string { return &s }
+
+func TestApplyWalk(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Fields["Address"] = Rule{Strategy: StrategyRedact}
+	m, err := NewMasker(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "budi@example.com"
+	contactPhone := "+628123456789"
+	newCustomer := func() *maskCustomer {
+		return &maskCustomer{
+			ID:       "C001",
+			NIK:      "3201123456780001",
+			Email:    &email,
+			Address:  "Jl. Merdeka No. 1",
+			Contacts: []maskContact{{Name: "Siti", Phone: &contactPhone}, {Name: "Ani"}},
+			Primary:  &maskContact{Name: "Joko", Phone: strp("+628111222333")},
+			Extra:    &maskContact{Name: "Wati"},
+			Raw:      []byte("3201123456780001"),
+			History:  []maskDiff{{Field: "nik", OldValue: "3201999999990001"}, {Field: "status", OldValue: "active"}},
+			secret:   "3201123456780001",
+			Nested:   [1]maskContact{{Name: "Rudi"}},
+		}
+	}
+
+	c := newCustomer()
+	m.Apply(auth.WithPrincipal(context.Background(), &auth.Principal{Roles: []string{"analyst"}}), c)
+
+	want := &maskCustomer{
+		ID:       "C001",
+		NIK:      "3201********0001",
+		Email:    strp("b***@example.com"),
+		Address:  "****",
+		Contacts: []maskContact{{Name: "S***", Phone: strp("+62812****789")}, {Name: "A**"}},
+		Primary:  &maskContact{Name: "J***", Phone: strp("+62811****333")},
+		Extra:    &maskContact{Name: "W***"},
+		Raw:      []byte("3201123456780001"),
+		History:  []maskDiff{{Field: "nik", OldValue: "3201********0001"}, {Field: "status", OldValue: "active"}},
+		secret:   "3201123456780001",
+		Nested:   [1]maskContact{{Name: "R***"}},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("masked =\n%+v\nwant\n%+v", c, want)
+	}
+	// nilai yang ditunjuk pointer milik pemanggil tidak ikut berubah
+	if email != "budi@example.com" || contactPhone != "+628123456789" {
+		t.Errorf("shared pointers mutated: %q %q", email, contactPhone)
+	}
+
+	// admin ("*") melihat semua field utuh
+	c = newCustomer()
+	m.Apply(auth.WithPrincipal(context.Background(), &auth.Principal{Roles: []string{"analyst", "admin"}}), c)
+	if !reflect.DeepEqual(c, newCustomer()) {
+		t.Errorf("admin got masked values: %+v", c)
+	}
+}
+
+func TestMaskerRoles(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Unmasked["cs"] = []string{"phone_number", "email"}
+	m, err := NewMasker(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		wantPhone  string
+		wantMasked []string
+	}{
+		{
+			name:       "no principal masks everything",
+			wantPhone:  "+62812****789",
+			wantMasked: []string{"address", "email", "emergency_contact_name", "emergency_contact_phone", "nik", "phone_number"},
+		},
+		{
+			name:       "role without bypass",
+			principal:  &auth.Principal{Roles: []string{"analyst"}},
+			wantPhone:  "+62812****789",
+			wantMasked: []string{"address", "email", "emergency_contact_name", "emergency_contact_phone", "nik", "phone_number"},
+		},
+		{
+			name:       "role with field bypass",
+			principal:  &auth.Principal{Roles: []string{"analyst", "cs"}},
+			wantPhone:  "+628123456789",
+			wantMasked: []string{"address", "emergency_contact_name", "emergency_contact_phone", "nik"},
+		},
+		{
+			name:       "wildcard bypass",
+			principal:  &auth.Principal{Roles: []string{"admin"}},
+			wantPhone:  "+628123456789",
+			wantMasked: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			if got := m.Mask(ctx, "phone_number", "+628123456789"); got != tt.wantPhone {
+				t.Errorf("phone = %q, want %q", got, tt.wantPhone)
+			}
+			if got := m.Mask(ctx, "status", "active"); got != "active" {
+				t.Errorf("field without rule masked: %q", got)
+			}
+			if got := m.MaskedFields(ctx); !reflect.DeepEqual(got, tt.wantMasked) {
+				t.Errorf("masked fields = %v, want %v", got, tt.wantMasked)
+			}
+		})
+	}
+
+	var nilMasker *Masker
+	if got := nilMasker.Mask(context.Background(), "nik", "3201123456780001"); got != "3201123456780001" {
+		t.Errorf("nil masker = %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+// internal/pii/policy.go
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Strategi masking per field.
+const (
+	StrategyPartial = "partial" // sisakan keep_prefix & keep_suffix karakter, sisanya '*'
+	StrategyEmail   = "email"   // huruf pertama local part + domain: b***@example.com
+	StrategyRedact  = "redact"  // ganti seluruh nilai dengan "****"
+	StrategyNone    = "none"    // tidak dimasking (untuk mematikan default lewat file)
+)
+
+// Rule mengatur cara satu field dimasking.
+type Rule struct {
+	Strategy   string `json:"strategy"`
+	KeepPrefix int    `json:"keep_prefix,omitempty"`
+	KeepSuffix int    `json:"keep_suffix,omitempty"`
+}
+
+// Policy: rule per field (nama field JSON = nama kolom) dan field yang boleh dilihat utuh per role.
+type Policy struct {
+	Fields map[string]Rule `json:"fields"`
+
+	// Unmasked: role -> field yang ditampilkan tanpa masking ("*" = semua field).
+	Unmasked map[string][]string `json:"unmasked,omitempty"`
+}
+
+// DefaultPolicy dipakai kalau PII_POLICY_FILE tidak di-set.
+// Contoh hasil: NIK 3201********0001, telepon +62812****789.
+func DefaultPolicy() Policy {
+	phone := Rule{Strategy: StrategyPartial, KeepPrefix: 6, KeepSuffix: 3}
+	return Policy{
+		Fields: map[string]Rule{
+			"nik":                     {Strategy: StrategyPartial, KeepPrefix: 4, KeepSuffix: 4},
+			"phone_number":            phone,
+			"email":                   {Strategy: StrategyEmail},
+			"address":                 {Strategy: StrategyRedact},
+			"emergency_contact_name":  {Strategy: StrategyPartial, KeepPrefix: 1},
+			"emergency_contact_phone": phone,
+		},
+		Unmasked: map[string][]string{"admin": {"*"}},
+	}
+}
+
+// LoadPolicy membaca policy dari file JSON (format sama dengan Policy). Field di file
+// menimpa default; field default yang tidak disebut tetap dimasking.
+func LoadPolicy(path string) (Policy, error) {
+	p := DefaultPolicy()
+	if path == "" {
+		return p, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("read PII policy: %w", err)
+	}
+	var file Policy
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return Policy{}, fmt.Errorf("parse PII policy %s: %w", path, err)
+	}
+
+	for field, rule := range file.Fields {
+		p.Fields[field] = rule
+	}
+	if file.Unmasked != nil {
+		p.Unmasked = file.Unmasked
+	}
+	return p, p.Validate()
+}
+
+// Validate memastikan strategi dikenal dan angka keep tidak negatif.
+func (p Policy) Validate() error {
+	fields := make([]string, 0, len(p.Fields))
+	for f := range p.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	for _, f := range fields {
+		r := p.Fields[f]
+		switch r.Strategy {
+		case StrategyPartial, StrategyEmail, StrategyRedact, StrategyNone:
+		default:
+			return fmt.Errorf("PII field %s: unknown strategy %q", f, r.Strategy)
+		}
+		if r.KeepPrefix < 0 || r.KeepSuffix < 0 {
+			return fmt.Errorf("PII field %s: keep_prefix/keep_suffix must be >= 0", f)
+		}
+	}
+	for role, list := range p.Unmasked {
+		for _, f := range list {
+			if f == "*" {
+				continue
+			}
+			if _, ok := p.Fields[f]; !ok {
+				return fmt.Errorf("PII unmasked role %s: unknown field %q", role, f)
+			}
+		}
+	}
+	return nil
+}
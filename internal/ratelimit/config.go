@@ -0,0 +1,108 @@
+// internal/ratelimit/config.go
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Rule mengatur satu route (atau default). Bucket dihitung per klien, MaxConcurrent per route.
+type Rule struct {
+	Rate          float64 `json:"rate"`                     // token per detik per klien; <= 0 = tanpa rate limit
+	Burst         int     `json:"burst,omitempty"`          // kapasitas bucket; 0 = ceil(rate)
+	MaxConcurrent int     `json:"max_concurrent,omitempty"` // request bersamaan (semua klien); 0 = tanpa batas
+}
+
+func (r Rule) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return int(math.Max(1, math.Ceil(r.Rate)))
+}
+
+// Config: rule default dan rule per route. Key route = pola chi, opsional diawali method:
+// "GET /api/v1/stats/kpi" atau "/api/v1/customers/{customer_id}/profile".
+type Config struct {
+	Default Rule            `json:"default"`
+	Routes  map[string]Rule `json:"routes"`
+}
+
+// DefaultConfig: health tanpa limit; stats (query agregat berat) dibatasi lebih ketat,
+// termasuk jumlah query bersamaan supaya tidak menghabiskan pool koneksi DB.
+func DefaultConfig(def Rule) Config {
+	return Config{
+		Default: def,
+		Routes: map[string]Rule{
+			"GET /api/v1/health":    {Rate: 0},
+			"GET /api/v1/stats/kpi": {Rate: 2, Burst: 10, MaxConcurrent: 4},
+		},
+	}
+}
+
+// LoadConfig membaca file JSON (format sama dengan Config). Route di file menimpa/menambah
+// route bawaan; "default" di file menimpa default dari env.
+func LoadConfig(path string, def Rule) (Config, error) {
+	cfg := DefaultConfig(def)
+	if path == "" {
+		return cfg, cfg.Validate()
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("read rate limit file: %w", err)
+	}
+	var file struct {
+		Default *Rule           `json:"default"`
+		Routes  map[string]Rule `json:"routes"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return cfg, fmt.Errorf("parse rate limit file %s: %w", path, err)
+	}
+	if file.Default != nil {
+		cfg.Default = *file.Default
+	}
+	for route, rule := range file.Routes {
+		cfg.Routes[route] = rule
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	routes := make([]string, 0, len(c.Routes))
+	for route := range c.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	check := func(name string, r Rule) error {
+		if r.Burst < 0 || r.MaxConcurrent < 0 {
+			return fmt.Errorf("rate limit %s: burst and max_concurrent must be >= 0", name)
+		}
+		return nil
+	}
+	if err := check("default", c.Default); err != nil {
+		return err
+	}
+	for _, route := range routes {
+		pattern := route
+		if method, rest, ok := strings.Cut(route, " "); ok {
+			if strings.ToUpper(method) != method {
+				return fmt.Errorf("rate limit route %q: method must be upper case", route)
+			}
+			pattern = rest
+		}
+		if !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("rate limit route %q: pattern must start with /", route)
+		}
+		if err := check(route, c.Routes[route]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// internal/ratelimit/limiter.go
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mini-poc-02/backend/internal/auth"
+)
+
+// sweepInterval: jarak minimum antar pembersihan bucket klien yang sudah penuh lagi.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Duration // waktu isi ulang dari kosong; bucket idle selama ini sudah penuh
+}
+
+// Limiter menerapkan token bucket per (route, klien) dan batas concurrency per route.
+// Harus dipasang setelah routing (r.With / di dalam r.Group) supaya pola route chi tersedia.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket // key "<rule>|<klien>"
+	lastSweep time.Time
+	sems      map[string]chan struct{} // per rule dengan MaxConcurrent > 0
+}
+
+func New(cfg Config) (*Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	l := &Limiter{
+		cfg:     cfg,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+		sems:    map[string]chan struct{}{},
+	}
+	if cfg.Default.MaxConcurrent > 0 {
+		l.sems["default"] = make(chan struct{}, cfg.Default.MaxConcurrent)
+	}
+	for name, r := range cfg.Routes {
+		if r.MaxConcurrent > 0 {
+			l.sems[name] = make(chan struct{}, r.MaxConcurrent)
+		}
+	}
+	return l, nil
+}
+
+// Middleware: Limiter nil = rate limit dimatikan.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return l.middleware(next, false)
+}
+
+// StreamMiddleware untuk route SSE: token bucket tetap berlaku, tetapi koneksi long-lived
+// tidak memakai slot concurrency rule default (slot baru dilepas saat stream selesai, jadi
+// beberapa dashboard terbuka akan menghabiskan jatah semua route lain). Batas concurrency
+// stream hanya berlaku lewat rule route-nya sendiri.
+func (l *Limiter) StreamMiddleware(next http.Handler) http.Handler {
+	return l.middleware(next, true)
+}
+
+func (l *Limiter) middleware(next http.Handler, stream bool) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, rule := l.ruleFor(r)
+
+		if rule.Rate > 0 {
+			ok, remaining, retryAfter, reset := l.take(name+"|"+clientKey(r), rule)
+			burst := rule.burst()
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", burst, ceilSeconds(time.Duration(float64(burst)/rule.Rate*float64(time.Second)))))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+			if !ok {
+				tooMany(w, "rate_limited", "rate limit exceeded for "+name, ceilSeconds(retryAfter))
+				return
+			}
+		}
+
+		if sem, ok := l.sems[name]; ok && !(stream && name == "default") {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			default:
+				tooMany(w, "concurrency_limited", fmt.Sprintf("too many concurrent requests for %s (max %d)", name, cap(sem)), 1)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ruleFor mencari rule berdasarkan "METHOD pola", lalu "pola", lalu default.
+func (l *Limiter) ruleFor(r *http.Request) (string, Rule) {
+	if rc := chi.RouteContext(r.Context()); rc != nil {
+		pattern := rc.RoutePattern()
+		for _, name := range []string{r.Method + " " + pattern, pattern} {
+			if rule, ok := l.cfg.Routes[name]; ok {
+				return name, rule
+			}
+		}
+	}
+	return "default", l.cfg.Default
+}
+
+// take mengambil satu token. remaining = token tersisa, retryAfter = sampai token berikutnya,
+// reset = sampai bucket penuh lagi.
+func (l *Limiter) take(key string, rule Rule) (ok bool, remaining int, retryAfter, reset time.Duration) {
+	now := l.now()
+	burst := float64(rule.burst())
+	perToken := time.Duration(float64(time.Second) / rule.Rate)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: burst, last: now, full: time.Duration(burst * float64(perToken))}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		ok = true
+	} else {
+		retryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	reset = time.Duration((burst - b.tokens) * float64(perToken))
+	return ok, int(b.tokens), retryAfter, reset
+}
+
+// sweep membuang bucket yang sudah idle cukup lama untuk penuh lagi (setara bucket baru).
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= b.full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientKey: API key, lalu user (sub token), lalu IP. RemoteAddr adalah peer socket, atau IP
+// klien dari X-Forwarded-For kalau peer adalah proxy terpercaya (realip.Resolver); header dari
+// klien langsung tidak dipakai, jadi IP palsu tidak bisa dipakai untuk menghindari limit.
+func clientKey(r *http.Request) string {
+	if p := auth.PrincipalFrom(r.Context()); p != nil {
+		if p.Scopes != nil {
+			return p.Subject // "apikey:<id>"
+		}
+		return "user:" + p.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func tooMany(w http.ResponseWriter, code, msg string, retryAfter int) {
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": msg, "code": code, "retry_after_seconds": retryAfter})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
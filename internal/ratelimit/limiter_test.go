@@ -0,0 +1,263 @@
+// internal/ratelimit/limiter_test.go
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeClock: jam yang bisa dimajukan manual untuk Limiter.now.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time      { return c.t }
+func (c *fakeClock) add(d time.Duration) { c.t = c.t.Add(d) }
+func newClock() *fakeClock               { return &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)} }
+
+func newTestLimiter(t *testing.T, cfg Config) *Limiter {
+	t.Helper()
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestTakeBurstAndRefill(t *testing.T) {
+	clock := newClock()
+	l := newTestLimiter(t, Config{})
+	l.now = clock.now
+	rule := Rule{Rate: 2, Burst: 3} // 1 token tiap 500ms
+
+	for i := 3; i > 0; i-- {
+		ok, remaining, _, _ := l.take("k", rule)
+		if !ok || remaining != i-1 {
+			t.Fatalf("take %d: ok=%v remaining=%d, want ok remaining=%d", 4-i, ok, remaining, i-1)
+		}
+	}
+
+	ok, remaining, retryAfter, reset := l.take("k", rule)
+	if ok || remaining != 0 {
+		t.Fatalf("burst exhausted: ok=%v remaining=%d", ok, remaining)
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("retryAfter = %v, want 500ms", retryAfter)
+	}
+	if reset != 1500*time.Millisecond {
+		t.Errorf("reset = %v, want 1.5s", reset)
+	}
+
+	clock.add(500 * time.Millisecond)
+	if ok, _, _, _ := l.take("k", rule); !ok {
+		t.Error("no token after 500ms refill")
+	}
+	if ok, _, _, _ := l.take("k", rule); ok {
+		t.Error("second token after only one refill interval")
+	}
+
+	// idle lama: bucket penuh lagi tapi tidak melebihi burst
+	clock.add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _, _, _ := l.take("k", rule); !ok {
+			t.Fatalf("take %d after idle: rejected", i+1)
+		}
+	}
+	if ok, _, _, _ := l.take("k", rule); ok {
+		t.Error("bucket refilled beyond burst")
+	}
+
+	// klien lain punya bucket sendiri
+	if ok, _, _, _ := l.take("other", rule); !ok {
+		t.Error("separate key shares bucket")
+	}
+}
+
+func TestRuleFor(t *testing.T) {
+	l := newTestLimiter(t, Config{
+		Default: Rule{Rate: 20},
+		Routes: map[string]Rule{
+			"GET /api/v1/customers/{customer_id}/profile": {Rate: 1},
+			"/api/v1/customers/{customer_id}/profile":     {Rate: 2},
+			"/api/v1/stats/kpi":                           {Rate: 3},
+		},
+	})
+
+	tests := []struct {
+		method, path string
+		wantName     string
+		wantRate     float64
+	}{
+		{http.MethodGet, "/api/v1/customers/C1/profile", "GET /api/v1/customers/{customer_id}/profile", 1},
+		{http.MethodPost, "/api/v1/customers/C1/profile", "/api/v1/customers/{customer_id}/profile", 2},
+		{http.MethodGet, "/api/v1/stats/kpi", "/api/v1/stats/kpi", 3},
+		{http.MethodGet, "/api/v1/customers", "default", 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var gotName string
+			var gotRule Rule
+			r := chi.NewRouter()
+			h := func(w http.ResponseWriter, r *http.Request) { gotName, gotRule = l.ruleFor(r) }
+			r.Get("/api/v1/customers/{customer_id}/profile", h)
+			r.Post("/api/v1/customers/{customer_id}/profile", h)
+			r.Get("/api/v1/stats/kpi", h)
+			r.Get("/api/v1/customers", h)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if gotName != tt.wantName || gotRule.Rate != tt.wantRate {
+				t.Errorf("rule = %q (rate %v), want %q (rate %v)", gotName, gotRule.Rate, tt.wantName, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	l := newTestLimiter(t, Config{
+		Default: Rule{MaxConcurrent: 1},
+		Routes:  map[string]Rule{"GET /api/v1/stream/dashboard": {MaxConcurrent: 1}},
+	})
+
+	release := make(chan struct{})
+	entered := make(chan struct{}, 4)
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+	r := chi.NewRouter()
+	r.With(l.Middleware).Get("/api/v1/customers", blocking)
+	r.With(l.Middleware).Get("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {})
+	r.With(l.StreamMiddleware).Get("/api/v1/customers/{customer_id}/events", blocking)
+	r.With(l.StreamMiddleware).Get("/api/v1/stream/dashboard", blocking)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	done := make(chan struct{})
+	start := func(path string) {
+		go func() { serve(path); done <- struct{}{} }()
+		<-entered
+	}
+
+	// stream tanpa rule sendiri tidak memakai slot default
+	start("/api/v1/customers/C1/events")
+	start("/api/v1/customers/C2/events")
+	// request biasa memakai satu-satunya slot default
+	start("/api/v1/customers")
+
+	rec := serve("/api/v1/health")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second default request: status %d, want 429", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "concurrency_limited") || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("429 body/header: %s %v", rec.Body, rec.Header())
+	}
+
+	// stream dengan rule sendiri tetap dibatasi rule itu
+	start("/api/v1/stream/dashboard")
+	if rec := serve("/api/v1/stream/dashboard"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second dashboard stream: status %d, want 429", rec.Code)
+	}
+
+	close(release)
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	if rec := serve("/api/v1/health"); rec.Code != http.StatusOK {
+		t.Errorf("after release: status %d, want 200", rec.Code)
+	}
+}
+
+func TestMiddlewareRateLimited(t *testing.T) {
+	clock := newClock()
+	l := newTestLimiter(t, Config{Default: Rule{Rate: 1, Burst: 1}})
+	l.now = clock.now
+
+	r := chi.NewRouter()
+	r.With(l.Middleware).Get("/api/v1/customers", func(w http.ResponseWriter, r *http.Request) {})
+	serve := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/customers", nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("198.51.100.7:1000"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("first request: %d %v", rec.Code, rec.Header())
+	}
+	rec := serve("198.51.100.7:2000")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("second request same IP: %d Retry-After=%q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := serve("203.0.113.9:1000"); rec.Code != http.StatusOK {
+		t.Errorf("other IP: %d, want 200", rec.Code)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "defaults", cfg: DefaultConfig(Rule{Rate: 20, Burst: 40})},
+		{name: "negative burst", cfg: Config{Default: Rule{Burst: -1}}, wantErr: "burst and max_concurrent"},
+		{name: "negative max_concurrent on route", cfg: Config{Routes: map[string]Rule{"/api/v1/customers": {MaxConcurrent: -1}}}, wantErr: "burst and max_concurrent"},
+		{name: "lower case method", cfg: Config{Routes: map[string]Rule{"get /api/v1/customers": {}}}, wantErr: "upper case"},
+		{name: "pattern without slash", cfg: Config{Routes: map[string]Rule{"GET api/v1/customers": {}}}, wantErr: "must start with /"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	cfg, err := LoadConfig(write("ok.json", `{"default":{"rate":5},"routes":{"GET /api/v1/stats/kpi":{"rate":1}}}`), Rule{Rate: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Default.Rate != 5 {
+		t.Errorf("default rate = %v, want 5 from file", cfg.Default.Rate)
+	}
+	if got := cfg.Routes["GET /api/v1/stats/kpi"]; got.Rate != 1 || got.MaxConcurrent != 0 {
+		t.Errorf("kpi rule = %+v, want file rule replacing built-in", got)
+	}
+	if _, ok := cfg.Routes["GET /api/v1/health"]; !ok {
+		t.Error("built-in health rule dropped")
+	}
+
+	if _, err := LoadConfig(write("unknown.json", `{"routes":{"/x":{"rps":1}}}`), Rule{}); err == nil {
+		t.Error("unknown field accepted")
+	}
+	if _, err := LoadConfig(write("bad.json", `{"routes":{"x":{"rate":1}}}`), Rule{}); err == nil {
+		t.Error("invalid route accepted")
+	}
+}
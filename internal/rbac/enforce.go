@@ -0,0 +1,185 @@
+// internal/rbac/enforce.go
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"mini-poc-02/backend/internal/auth"
+)
+
+// RowScope adalah satu set syarat kolom = nilai (AND). Beberapa RowScope digabung dengan OR.
+type RowScope map[string]string
+
+// Decision adalah hasil otorisasi satu permission untuk satu principal.
+type Decision struct {
+	Allowed bool
+	// Scopes nil = tanpa batasan baris; selain itu baris harus cocok salah satu scope.
+	Scopes []RowScope
+	Reason string
+}
+
+// Enforcer menerapkan Policy pada principal dari context (lihat auth.Middleware).
+type Enforcer struct {
+	Policy Policy
+}
+
+func NewEnforcer(p Policy) (*Enforcer, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &Enforcer{Policy: p}, nil
+}
+
+// Decide menghitung keputusan untuk permission. Role yang scope-nya butuh claim yang tidak
+// ada di token tidak memberi akses sama sekali (fail closed).
+func (e *Enforcer) Decide(p *auth.Principal, perm string) Decision {
+	if p == nil {
+		return Decision{Reason: "no authenticated principal"}
+	}
+	if p.Scopes != nil {
+		// API key: scope = permission; batasan baris diwarisi dari pembuat key
+		if grants(Role{Permissions: p.Scopes}, perm) {
+			d := Decision{Allowed: true}
+			for _, rs := range p.RowScopes[perm] {
+				d.Scopes = append(d.Scopes, RowScope(rs))
+			}
+			return d
+		}
+		return Decision{Reason: "API key scopes do not include " + perm}
+	}
+
+	d := Decision{}
+	unrestricted := false
+	missingClaims := []string{}
+	for _, name := range p.Roles {
+		role, ok := e.Policy.Roles[name]
+		if !ok || !grants(role, perm) {
+			continue
+		}
+		if len(role.Scope) == 0 {
+			unrestricted = true
+			continue
+		}
+		scope := RowScope{}
+		for col, claim := range role.Scope {
+			v := claimString(p.Claims[claim])
+			if v == "" {
+				missingClaims = append(missingClaims, claim)
+				scope = nil
+				break
+			}
+			scope[col] = v
+		}
+		if scope != nil {
+			d.Scopes = append(d.Scopes, scope)
+		}
+	}
+
+	switch {
+	case unrestricted:
+		return Decision{Allowed: true}
+	case len(d.Scopes) > 0:
+		d.Allowed = true
+		return d
+	case len(missingClaims) > 0:
+		return Decision{Reason: "token is missing scope claim(s): " + strings.Join(missingClaims, ", ")}
+	default:
+		return Decision{Reason: "no role grants " + perm}
+	}
+}
+
+// Require mengembalikan middleware per route. Enforcer nil = RBAC dimatikan.
+func (e *Enforcer) Require(perm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if e == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := auth.PrincipalFrom(r.Context())
+			d := e.Decide(p, perm)
+			if !d.Allowed {
+				roles := []string{}
+				if p != nil {
+					roles = p.Roles
+				}
+				WriteForbidden(w, Forbidden{
+					Code:       "missing_permission",
+					Permission: perm,
+					Roles:      roles,
+					Message:    d.Reason,
+				})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withDecision(r.Context(), d)))
+		})
+	}
+}
+
+// Forbidden adalah body 403 yang konsisten untuk semua penolakan otorisasi.
+type Forbidden struct {
+	Code        string   `json:"code"` // missing_permission | out_of_scope | scope_not_held
+	Permission  string   `json:"permission,omitempty"`
+	Permissions []string `json:"permissions,omitempty"` // beberapa permission yang ditolak sekaligus
+	Roles       []string `json:"roles,omitempty"`
+	Message     string   `json:"message"`
+}
+
+func WriteForbidden(w http.ResponseWriter, f Forbidden) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": "forbidden", "forbidden": f})
+}
+
+type decisionKey struct{}
+
+func withDecision(ctx context.Context, d Decision) context.Context {
+	return context.WithValue(ctx, decisionKey{}, d)
+}
+
+// ScopesFrom mengembalikan batasan baris untuk request ini (nil = tanpa batasan).
+func ScopesFrom(ctx context.Context) []RowScope {
+	d, _ := ctx.Value(decisionKey{}).(Decision)
+	return d.Scopes
+}
+
+// Predicate membangun predikat SQL dari scope request, dengan addArg untuk placeholder.
+// prefix adalah alias tabel (mis. "c." atau ""). Kosong kalau tanpa batasan.
+func Predicate(ctx context.Context, prefix string, addArg func(any) string) string {
+	scopes := ScopesFrom(ctx)
+	if len(scopes) == 0 {
+		return ""
+	}
+	ors := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		cols := make([]string, 0, len(s))
+		for col := range s {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+
+		ands := make([]string, 0, len(cols))
+		for _, col := range cols {
+			// col sudah divalidasi terhadap ScopeColumns saat policy dimuat
+			ands = append(ands, fmt.Sprintf("%s%s = %s", prefix, col, addArg(s[col])))
+		}
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func claimString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case fmt.Stringer:
+		return x.String()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,200 @@
+// internal/rbac/enforce_test.go
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mini-poc-02/backend/internal/auth"
+)
+
+func testEnforcer(t *testing.T) *Enforcer {
+	t.Helper()
+	p := DefaultPolicy()
+	// role dengan dua kolom scope (AND)
+	p.Roles["branch_segment"] = Role{
+		Permissions: []string{PermCustomersList},
+		Scope:       map[string]string{"province": "province", "customer_segment": "segment"},
+	}
+	e, err := NewEnforcer(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestDecide(t *testing.T) {
+	e := testEnforcer(t)
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		perm       string
+		wantAllow  bool
+		wantScopes []RowScope
+		wantReason string // substring
+	}{
+		{name: "no principal", perm: PermStatsRead, wantReason: "no authenticated principal"},
+		{name: "admin wildcard", principal: &auth.Principal{Roles: []string{"admin"}}, perm: PermCustomersReveal, wantAllow: true},
+		{name: "group wildcard", principal: &auth.Principal{Roles: []string{"ops"}}, perm: PermSyncWrite, wantAllow: true},
+		{name: "no role grants", principal: &auth.Principal{Roles: []string{"analyst"}}, perm: PermCustomersList, wantReason: "no role grants customers:list"},
+		{name: "unknown role", principal: &auth.Principal{Roles: []string{"intern"}}, perm: PermStatsRead, wantReason: "no role grants"},
+		{
+			name:       "scoped role",
+			principal:  &auth.Principal{Roles: []string{"branch_province"}, Claims: map[string]any{"province": "Jawa Barat"}},
+			perm:       PermCustomersList,
+			wantAllow:  true,
+			wantScopes: []RowScope{{"province": "Jawa Barat"}},
+		},
+		{
+			name:       "missing scope claim fails closed",
+			principal:  &auth.Principal{Roles: []string{"branch_city"}, Claims: map[string]any{"province": "Jawa Barat"}},
+			perm:       PermCustomersList,
+			wantReason: "missing scope claim(s): city",
+		},
+		{
+			name:       "non-string claim counts as missing",
+			principal:  &auth.Principal{Roles: []string{"branch_city"}, Claims: map[string]any{"city": 42}},
+			perm:       PermCustomersList,
+			wantReason: "missing scope claim(s): city",
+		},
+		{
+			name:       "partly missing claims on multi-column scope",
+			principal:  &auth.Principal{Roles: []string{"branch_segment"}, Claims: map[string]any{"province": "Bali"}},
+			perm:       PermCustomersList,
+			wantReason: "missing scope claim(s): segment",
+		},
+		{
+			name: "several scoped roles combine with OR",
+			principal: &auth.Principal{Roles: []string{"branch_province", "branch_city"},
+				Claims: map[string]any{"province": "Jawa Barat", "city": "Surabaya"}},
+			perm:       PermCustomersProfile,
+			wantAllow:  true,
+			wantScopes: []RowScope{{"province": "Jawa Barat"}, {"city": "Surabaya"}},
+		},
+		{
+			name: "scoped role with missing claim is skipped when another grants",
+			principal: &auth.Principal{Roles: []string{"branch_province", "branch_city"},
+				Claims: map[string]any{"province": "Jawa Barat"}},
+			perm:       PermCustomersList,
+			wantAllow:  true,
+			wantScopes: []RowScope{{"province": "Jawa Barat"}},
+		},
+		{
+			name: "unrestricted role wins over scoped role",
+			principal: &auth.Principal{Roles: []string{"branch_province", "admin"},
+				Claims: map[string]any{"province": "Jawa Barat"}},
+			perm:      PermCustomersList,
+			wantAllow: true,
+		},
+		{
+			name:      "api key scope",
+			principal: &auth.Principal{Roles: []string{"admin"}, Scopes: []string{"stats:*"}},
+			perm:      PermStatsRead,
+			wantAllow: true,
+		},
+		{
+			name:       "api key ignores roles",
+			principal:  &auth.Principal{Roles: []string{"admin"}, Scopes: []string{"stats:*"}},
+			perm:       PermCustomersList,
+			wantReason: "API key scopes do not include customers:list",
+		},
+		{
+			name:       "api key with empty scopes",
+			principal:  &auth.Principal{Scopes: []string{}},
+			perm:       PermStatsRead,
+			wantReason: "API key scopes do not include",
+		},
+		{
+			name: "api key inherits row scope of its creator",
+			principal: &auth.Principal{
+				Scopes:    []string{PermCustomersList, PermCustomersProfile},
+				RowScopes: map[string][]map[string]string{PermCustomersList: {{"province": "Jawa Barat"}, {"city": "Surabaya"}}},
+			},
+			perm:       PermCustomersList,
+			wantAllow:  true,
+			wantScopes: []RowScope{{"province": "Jawa Barat"}, {"city": "Surabaya"}},
+		},
+		{
+			name: "api key permission without row scope is unrestricted",
+			principal: &auth.Principal{
+				Scopes:    []string{PermCustomersList, PermCustomersProfile},
+				RowScopes: map[string][]map[string]string{PermCustomersList: {{"province": "Jawa Barat"}}},
+			},
+			perm:      PermCustomersProfile,
+			wantAllow: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := e.Decide(tt.principal, tt.perm)
+			if d.Allowed != tt.wantAllow {
+				t.Fatalf("allowed = %v, want %v (reason %q)", d.Allowed, tt.wantAllow, d.Reason)
+			}
+			if !reflect.DeepEqual(d.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", d.Scopes, tt.wantScopes)
+			}
+			if !strings.Contains(d.Reason, tt.wantReason) {
+				t.Errorf("reason = %q, want containing %q", d.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPredicate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []RowScope
+		prefix   string
+		wantSQL  string
+		wantArgs []any
+	}{
+		{name: "no decision", wantSQL: ""},
+		{name: "unrestricted", scopes: []RowScope{}, wantSQL: ""},
+		{
+			name:     "single scope",
+			scopes:   []RowScope{{"province": "Jawa Barat"}},
+			prefix:   "c.",
+			wantSQL:  "((c.province = $3))",
+			wantArgs: []any{"Jawa Barat"},
+		},
+		{
+			name:     "multi-column scope sorted by column",
+			scopes:   []RowScope{{"province": "Bali", "customer_segment": "retail"}},
+			wantSQL:  "((customer_segment = $3 AND province = $4))",
+			wantArgs: []any{"retail", "Bali"},
+		},
+		{
+			name:     "several scopes OR-ed",
+			scopes:   []RowScope{{"province": "Jawa Barat"}, {"city": "Surabaya"}, {"province": "Bali", "customer_segment": "retail"}},
+			prefix:   "c.",
+			wantSQL:  "((c.province = $3) OR (c.city = $4) OR (c.customer_segment = $5 AND c.province = $6))",
+			wantArgs: []any{"Jawa Barat", "Surabaya", "retail", "Bali"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.scopes != nil {
+				ctx = withDecision(ctx, Decision{Allowed: true, Scopes: tt.scopes})
+			}
+			// dua argumen sudah dipakai query sebelum predikat
+			args := []any{"x", "y"}
+			addArg := func(v any) string {
+				args = append(args, v)
+				return fmt.Sprintf("$%d", len(args))
+			}
+
+			got := Predicate(ctx, tt.prefix, addArg)
+			if got != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", got, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args[2:], append([]any{}, tt.wantArgs...)) {
+				t.Errorf("args = %v, want %v", args[2:], tt.wantArgs)
+			}
+		})
+	}
+}
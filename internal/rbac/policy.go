@@ -0,0 +1,139 @@
+// internal/rbac/policy.go
+package rbac
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Permission yang dipakai route di httpapi.NewRouter.
+const (
+	PermCustomersList    = "customers:list"    // GET /customers
+	PermCustomersProfile = "customers:profile" // profile, diff, history, timeline, events
+	PermCustomersReveal  = "customers:reveal"  // POST /customers/{id}/reveal (PII tanpa masking)
+	PermStatsRead        = "stats:read"        // /stats/*, /stream/dashboard
+	PermSyncRead         = "sync:read"         // GET /sync/*
+	PermSyncWrite        = "sync:write"        // POST /sync/reconciliation/run
+	PermAuditRead        = "audit:read"        // GET /audit
+	PermAPIKeysManage    = "apikeys:manage"    // /admin/api-keys
+	PermMetricsRead      = "metrics:read"      // GET /metrics (kecuali METRICS_PUBLIC)
+)
+
+// Permissions: semua permission yang dikenal (untuk validasi scope API key).
+var Permissions = []string{
+	PermCustomersList, PermCustomersProfile, PermCustomersReveal,
+	PermStatsRead, PermSyncRead, PermSyncWrite, PermAuditRead, PermAPIKeysManage,
+	PermMetricsRead,
+}
+
+// Role memberi sekumpulan permission, opsional dibatasi ke sebagian baris customer.
+type Role struct {
+	// Permissions boleh wildcard: "*" atau "customers:*".
+	Permissions []string `json:"permissions"`
+
+	// Scope membatasi baris customer: kolom -> nama claim token, mis. {"province": "province"}.
+	// Semua kolom harus cocok (AND). Kosong = semua baris.
+	Scope map[string]string `json:"scope,omitempty"`
+}
+
+// Policy memetakan role (dari claim token) ke permission & scope.
+type Policy struct {
+	Roles map[string]Role `json:"roles"`
+}
+
+// ScopeColumns: kolom customers yang boleh dipakai untuk scope (dirangkai ke SQL).
+var ScopeColumns = []string{"province", "city", "customer_segment"}
+
+var permRe = regexp.MustCompile(`^(\*|[a-z_]+:(\*|[a-z_]+))$`)
+
+// DefaultPolicy dipakai kalau RBAC_POLICY_FILE tidak di-set.
+func DefaultPolicy() Policy {
+	return Policy{Roles: map[string]Role{
+		"admin":   {Permissions: []string{"*"}},
+		"analyst": {Permissions: []string{PermStatsRead}},
+		"branch_province": {
+			Permissions: []string{PermCustomersList, PermCustomersProfile},
+			Scope:       map[string]string{"province": "province"},
+		},
+		"branch_city": {
+			Permissions: []string{PermCustomersList, PermCustomersProfile},
+			Scope:       map[string]string{"city": "city"},
+		},
+		"ops":        {Permissions: []string{"sync:*", PermStatsRead, PermMetricsRead}},
+		"compliance": {Permissions: []string{PermAuditRead}},
+	}}
+}
+
+// LoadPolicy membaca policy dari file JSON (format sama dengan Policy).
+func LoadPolicy(path string) (Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("read RBAC policy: %w", err)
+	}
+	var p Policy
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return Policy{}, fmt.Errorf("parse RBAC policy %s: %w", path, err)
+	}
+	return p, p.Validate()
+}
+
+// Validate memastikan permission berformat benar dan kolom scope ada di whitelist.
+func (p Policy) Validate() error {
+	if len(p.Roles) == 0 {
+		return fmt.Errorf("RBAC policy has no roles")
+	}
+	names := make([]string, 0, len(p.Roles))
+	for name := range p.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		role := p.Roles[name]
+		for _, perm := range role.Permissions {
+			if !permRe.MatchString(perm) {
+				return fmt.Errorf("role %s: invalid permission %q", name, perm)
+			}
+		}
+		for col, claim := range role.Scope {
+			if !validScopeColumn(col) {
+				return fmt.Errorf("role %s: scope column %q not allowed (use %s)", name, col, strings.Join(ScopeColumns, ", "))
+			}
+			if claim == "" {
+				return fmt.Errorf("role %s: scope column %q has no claim", name, col)
+			}
+		}
+	}
+	return nil
+}
+
+func validScopeColumn(col string) bool {
+	for _, c := range ScopeColumns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+func grants(role Role, perm string) bool {
+	for _, p := range role.Permissions {
+		if p == "*" || p == perm {
+			return true
+		}
+		if strings.HasSuffix(p, ":*") && strings.HasPrefix(perm, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
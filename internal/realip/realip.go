@@ -0,0 +1,107 @@
+// internal/realip/realip.go
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver menentukan IP klien untuk rate limit, audit dan access log. Header
+// X-Forwarded-For / X-Real-IP hanya dipercaya kalau peer socket adalah proxy terpercaya;
+// dari klien lain header itu bisa dipalsukan sesuka hati.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New menerima daftar IP atau CIDR proxy terpercaya (mis. "10.0.0.0/8", "127.0.0.1").
+func New(trustedProxies []string) (*Resolver, error) {
+	rv := &Resolver{}
+	for _, s := range trustedProxies {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+			}
+			rv.trusted = append(rv.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		rv.trusted = append(rv.trusted, p.Masked())
+	}
+	return rv, nil
+}
+
+// Middleware mengganti r.RemoteAddr dengan IP klien (tanpa port) kalau request datang
+// lewat proxy terpercaya. Resolver nil = RemoteAddr tidak diubah (IP peer socket).
+func (rv *Resolver) Middleware(next http.Handler) http.Handler {
+	if rv == nil || len(rv.trusted) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := rv.ClientIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP mengembalikan IP klien dari sudut pandang proxy terpercaya. X-Forwarded-For
+// dibaca dari kanan: entri yang juga proxy terpercaya dilewati, entri pertama yang bukan
+// proxy adalah klien (entri di kirinya bisa ditulis klien sendiri). "" = pakai peer socket.
+func (rv *Resolver) ClientIP(r *http.Request) string {
+	peer, ok := parseIP(r.RemoteAddr)
+	if !ok || !rv.isTrusted(peer) {
+		return ""
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseIP(hops[i])
+		if !ok {
+			return "" // rantai rusak: jangan menebak
+		}
+		if i == 0 || !rv.isTrusted(ip) {
+			return ip.String()
+		}
+	}
+
+	if ip, ok := parseIP(r.Header.Get("X-Real-IP")); ok {
+		return ip.String()
+	}
+	return ""
+}
+
+func (rv *Resolver) isTrusted(ip netip.Addr) bool {
+	for _, p := range rv.trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP menerima "ip", "ip:port" dan "[ipv6]:port".
+func parseIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
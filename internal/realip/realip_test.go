@@ -0,0 +1,82 @@
+// internal/realip/realip_test.go
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	rv, err := New([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		xrip   string
+		want   string // RemoteAddr setelah middleware
+	}{
+		{name: "direct client spoofing XFF", remote: "203.0.113.9:5555", xff: []string{"1.2.3.4"}, want: "203.0.113.9:5555"},
+		{name: "direct client spoofing X-Real-IP", remote: "203.0.113.9:5555", xrip: "1.2.3.4", want: "203.0.113.9:5555"},
+		{name: "trusted proxy", remote: "10.1.2.3:443", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "client prepends fake hop", remote: "10.1.2.3:443", xff: []string{"1.2.3.4, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "chain of trusted proxies", remote: "10.1.2.3:443", xff: []string{"198.51.100.7, 192.168.1.1", "10.9.9.9"}, want: "198.51.100.7"},
+		{name: "all hops trusted", remote: "10.1.2.3:443", xff: []string{"10.0.0.5"}, want: "10.0.0.5"},
+		{name: "X-Real-IP from trusted proxy", remote: "[::1]:443", xrip: "2001:db8::1", want: "2001:db8::1"},
+		{name: "malformed hop", remote: "10.1.2.3:443", xff: []string{"198.51.100.7, bogus"}, want: "10.1.2.3:443"},
+		{name: "trusted proxy without headers", remote: "10.1.2.3:443", want: "10.1.2.3:443"},
+		{name: "IPv4-mapped peer", remote: "[::ffff:10.1.2.3]:443", xff: []string{"198.51.100.7"}, want: "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/customers", nil)
+			req.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.xrip != "" {
+				req.Header.Set("X-Real-IP", tt.xrip)
+			}
+
+			var got string
+			rv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoTrustedProxies(t *testing.T) {
+	for _, rv := range []*Resolver{nil, {}} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.1.2.3:443"
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+
+		var got string
+		rv.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.RemoteAddr
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		if got != "10.1.2.3:443" {
+			t.Errorf("RemoteAddr = %q, want socket peer", got)
+		}
+	}
+}
+
+func TestNewRejectsInvalidEntries(t *testing.T) {
+	for _, in := range []string{"10.0.0.0/33", "proxy.internal", "10.0.0.1/"} {
+		if _, err := New([]string{in}); err == nil {
+			t.Errorf("New(%q): want error", in)
+		}
+	}
+	if _, err := New([]string{" 10.0.0.0/8 ", "", "2001:db8::/32"}); err != nil {
+		t.Errorf("New: %v", err)
+	}
+}
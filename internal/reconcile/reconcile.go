@@ -0,0 +1,445 @@
+// internal/reconcile/reconcile.go
+package reconcile
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TableSpec mendefinisikan tabel yang direkonsiliasi: primary key + kolom yang di-checksum.
+// Nama tabel/kolom bukan input user, jadi aman dirangkai ke SQL.
+type TableSpec struct {
+	Table      string
+	PrimaryKey string
+	Columns    []string
+}
+
+// DefaultTables: kolom yang paling sering berubah / paling penting untuk Customer 360.
+var DefaultTables = []TableSpec{
+	{
+		Table:      "customers",
+		PrimaryKey: "customer_id",
+		Columns: []string{
+			"nik", "full_name", "phone_number", "email", "address", "city", "province",
+			"customer_segment", "credit_score", "status", "last_updated",
+		},
+	},
+	{
+		Table:      "credit_applications",
+		PrimaryKey: "application_id",
+		Columns: []string{
+			"customer_id", "application_status", "loan_amount", "outstanding_amount",
+			"payment_status", "approval_date", "last_payment_date", "created_date",
+		},
+	},
+	{
+		Table:      "vehicle_ownership",
+		PrimaryKey: "ownership_id",
+		Columns: []string{
+			"customer_id", "ownership_status", "registration_number", "vehicle_price", "created_date",
+		},
+	},
+}
+
+type Config struct {
+	Tables    []TableSpec
+	ChunkSize int // jumlah primary key per chunk
+	MaxDiffs  int // maksimal id yang dicantumkan per jenis selisih (count tetap dihitung penuh)
+}
+
+// ChunkReport hanya dicantumkan untuk chunk yang checksum-nya berbeda.
+type ChunkReport struct {
+	FromKey        string `json:"from_key"` // eksklusif
+	ToKey          string `json:"to_key"`   // inklusif
+	SourceRows     int    `json:"source_rows"`
+	TargetRows     int    `json:"target_rows"`
+	SourceChecksum string `json:"source_checksum"`
+	TargetChecksum string `json:"target_checksum"`
+}
+
+type TableReport struct {
+	Table      string   `json:"table"`
+	PrimaryKey string   `json:"primary_key"`
+	Columns    []string `json:"columns"`
+
+	SourceCount int  `json:"source_count"`
+	TargetCount int  `json:"target_count"`
+	CountMatch  bool `json:"count_match"`
+
+	Chunks           int           `json:"chunks"`
+	ChunksMismatched int           `json:"chunks_mismatched"`
+	MismatchedChunks []ChunkReport `json:"mismatched_chunks,omitempty"`
+
+	// Missing = ada di MySQL tapi tidak ada di ODS; Extra = ada di ODS tapi tidak di MySQL;
+	// Mismatched = ada di keduanya tapi nilai kolom berbeda.
+	MissingCount    int      `json:"missing_count"`
+	ExtraCount      int      `json:"extra_count"`
+	MismatchedCount int      `json:"mismatched_count"`
+	Missing         []string `json:"missing"`
+	Extra           []string `json:"extra"`
+	Mismatched      []string `json:"mismatched"`
+	Truncated       bool     `json:"truncated"` // true kalau daftar id dipotong oleh MaxDiffs
+
+	Status string `json:"status"` // ok | mismatch | error
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	DurationMs int64         `json:"duration_ms"`
+	Status     string        `json:"status"` // ok | mismatch | error
+	Tables     []TableReport `json:"tables"`
+}
+
+// Reconciler membandingkan MySQL (source) dengan PostgreSQL (ODS).
+type Reconciler struct {
+	Source *sql.DB // MySQL, placeholder "?"
+	Target *sql.DB // PostgreSQL, placeholder "$n"
+	cfg    Config
+
+	mu      sync.Mutex
+	running bool
+	latest  *Report
+}
+
+var ErrAlreadyRunning = errors.New("reconciliation already running")
+
+func New(source, target *sql.DB, cfg Config) *Reconciler {
+	if len(cfg.Tables) == 0 {
+		cfg.Tables = DefaultTables
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = 1000
+	}
+	if cfg.MaxDiffs <= 0 {
+		cfg.MaxDiffs = 100
+	}
+	return &Reconciler{Source: source, Target: target, cfg: cfg}
+}
+
+// Latest mengembalikan hasil run terakhir (nil kalau belum pernah jalan) dan apakah run sedang berjalan.
+func (rc *Reconciler) Latest() (*Report, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.latest, rc.running
+}
+
+// Run menjalankan rekonsiliasi semua tabel. Hanya satu run yang boleh berjalan bersamaan.
+func (rc *Reconciler) Run(ctx context.Context) (*Report, error) {
+	if !rc.acquire() {
+		return nil, ErrAlreadyRunning
+	}
+	defer rc.release()
+	return rc.run(ctx), nil
+}
+
+// TryStart memulai run di background. false kalau run lain sedang berjalan; pengecekan dan
+// penandaan "running" atomik, jadi dua pemanggil bersamaan tidak bisa sama-sama memulai run.
+// done (opsional) dipanggil setelah run selesai.
+func (rc *Reconciler) TryStart(ctx context.Context, done func(*Report)) bool {
+	if !rc.acquire() {
+		return false
+	}
+	go func() {
+		defer rc.release()
+		rep := rc.run(ctx)
+		if done != nil {
+			done(rep)
+		}
+	}()
+	return true
+}
+
+func (rc *Reconciler) acquire() bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.running {
+		return false
+	}
+	rc.running = true
+	return true
+}
+
+func (rc *Reconciler) release() {
+	rc.mu.Lock()
+	rc.running = false
+	rc.mu.Unlock()
+}
+
+func (rc *Reconciler) run(ctx context.Context) *Report {
+	rep := &Report{StartedAt: time.Now().UTC(), Status: "ok"}
+	for _, spec := range rc.cfg.Tables {
+		tr := rc.reconcileTable(ctx, spec)
+		rep.Tables = append(rep.Tables, tr)
+		rep.Status = worse(rep.Status, tr.Status)
+	}
+	rep.FinishedAt = time.Now().UTC()
+	rep.DurationMs = rep.FinishedAt.Sub(rep.StartedAt).Milliseconds()
+
+	rc.mu.Lock()
+	rc.latest = rep
+	rc.mu.Unlock()
+	return rep
+}
+
+// Start menjalankan Run secara periodik sampai ctx selesai.
+func (rc *Reconciler) Start(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if rep, err := rc.Run(ctx); err != nil && !errors.Is(err, ErrAlreadyRunning) {
+			logger.Error("reconciliation failed", "error", err)
+		} else if rep != nil && rep.Status != "ok" {
+			logger.Warn("reconciliation finished with problems", "status", rep.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (rc *Reconciler) reconcileTable(ctx context.Context, spec TableSpec) TableReport {
+	tr := TableReport{
+		Table:      spec.Table,
+		PrimaryKey: spec.PrimaryKey,
+		Columns:    spec.Columns,
+		Missing:    []string{},
+		Extra:      []string{},
+		Mismatched: []string{},
+		Status:     "ok",
+	}
+
+	fail := func(err error) TableReport {
+		tr.Status = "error"
+		tr.Error = err.Error()
+		return tr
+	}
+
+	countQ := fmt.Sprintf("SELECT COUNT(*) FROM %s", spec.Table)
+	if err := rc.Source.QueryRowContext(ctx, countQ).Scan(&tr.SourceCount); err != nil {
+		return fail(fmt.Errorf("count source: %w", err))
+	}
+	if err := rc.Target.QueryRowContext(ctx, countQ).Scan(&tr.TargetCount); err != nil {
+		return fail(fmt.Errorf("count target: %w", err))
+	}
+	tr.CountMatch = tr.SourceCount == tr.TargetCount
+
+	// Keyset pagination berdasarkan urutan byte primary key di kedua sisi
+	// (BINARY di MySQL, COLLATE "C" di Postgres) supaya batas chunk selalu sejajar.
+	lastKey := ""
+	for {
+		src, err := rc.fetchSource(ctx, spec, lastKey, rc.cfg.ChunkSize)
+		if err != nil {
+			return fail(fmt.Errorf("read source chunk after %q: %w", lastKey, err))
+		}
+		if len(src) == 0 {
+			break
+		}
+		hi := src[len(src)-1].Key
+
+		tgt, err := rc.fetchTarget(ctx, spec, lastKey, &hi, 0)
+		if err != nil {
+			return fail(fmt.Errorf("read target chunk after %q: %w", lastKey, err))
+		}
+
+		tr.Chunks++
+		srcSum, tgtSum := chunkChecksum(src), chunkChecksum(tgt)
+		if srcSum != tgtSum {
+			tr.ChunksMismatched++
+			if len(tr.MismatchedChunks) < rc.cfg.MaxDiffs {
+				tr.MismatchedChunks = append(tr.MismatchedChunks, ChunkReport{
+					FromKey:        lastKey,
+					ToKey:          hi,
+					SourceRows:     len(src),
+					TargetRows:     len(tgt),
+					SourceChecksum: srcSum,
+					TargetChecksum: tgtSum,
+				})
+			}
+			rc.diffRows(&tr, src, tgt)
+		}
+
+		lastKey = hi
+		if len(src) < rc.cfg.ChunkSize {
+			break
+		}
+	}
+
+	// Sisa baris di ODS di luar key terakhir source = extra.
+	for {
+		tgt, err := rc.fetchTarget(ctx, spec, lastKey, nil, rc.cfg.ChunkSize)
+		if err != nil {
+			return fail(fmt.Errorf("read target tail after %q: %w", lastKey, err))
+		}
+		if len(tgt) == 0 {
+			break
+		}
+		tr.Chunks++
+		tr.ChunksMismatched++
+		for _, r := range tgt {
+			rc.addDiff(&tr, &tr.Extra, &tr.ExtraCount, r.Key)
+		}
+		lastKey = tgt[len(tgt)-1].Key
+		if len(tgt) < rc.cfg.ChunkSize {
+			break
+		}
+	}
+
+	if !tr.CountMatch || tr.ChunksMismatched > 0 {
+		tr.Status = "mismatch"
+	}
+	return tr
+}
+
+type keyedRow struct {
+	Key  string
+	Hash string
+}
+
+func (rc *Reconciler) fetchSource(ctx context.Context, spec TableSpec, after string, limit int) ([]keyedRow, error) {
+	q := fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE BINARY %s > ? ORDER BY BINARY %s LIMIT ?",
+		spec.PrimaryKey, strings.Join(spec.Columns, ", "), spec.Table,
+		spec.PrimaryKey, spec.PrimaryKey,
+	)
+	return scanKeyedRows(ctx, rc.Source, q, len(spec.Columns), after, limit)
+}
+
+// fetchTarget membaca baris ODS dengan key > after (dan <= upTo kalau diisi).
+func (rc *Reconciler) fetchTarget(ctx context.Context, spec TableSpec, after string, upTo *string, limit int) ([]keyedRow, error) {
+	cols := strings.Join(spec.Columns, ", ")
+	pk := spec.PrimaryKey
+	if upTo != nil {
+		q := fmt.Sprintf(
+			`SELECT %s, %s FROM %s WHERE %s COLLATE "C" > $1 AND %s COLLATE "C" <= $2 ORDER BY %s COLLATE "C"`,
+			pk, cols, spec.Table, pk, pk, pk,
+		)
+		return scanKeyedRows(ctx, rc.Target, q, len(spec.Columns), after, *upTo)
+	}
+	q := fmt.Sprintf(
+		`SELECT %s, %s FROM %s WHERE %s COLLATE "C" > $1 ORDER BY %s COLLATE "C" LIMIT $2`,
+		pk, cols, spec.Table, pk, pk,
+	)
+	return scanKeyedRows(ctx, rc.Target, q, len(spec.Columns), after, limit)
+}
+
+func scanKeyedRows(ctx context.Context, db *sql.DB, q string, ncols int, args ...any) ([]keyedRow, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vals := make([]sql.NullString, ncols+1)
+	dest := make([]any, ncols+1)
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+
+	out := make([]keyedRow, 0, 256)
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		out = append(out, keyedRow{Key: vals[0].String, Hash: rowHash(vals[1:])})
+	}
+	return out, rows.Err()
+}
+
+func (rc *Reconciler) diffRows(tr *TableReport, src, tgt []keyedRow) {
+	tgtByKey := make(map[string]string, len(tgt))
+	for _, r := range tgt {
+		tgtByKey[r.Key] = r.Hash
+	}
+	for _, r := range src {
+		h, ok := tgtByKey[r.Key]
+		switch {
+		case !ok:
+			rc.addDiff(tr, &tr.Missing, &tr.MissingCount, r.Key)
+		case h != r.Hash:
+			rc.addDiff(tr, &tr.Mismatched, &tr.MismatchedCount, r.Key)
+		}
+		delete(tgtByKey, r.Key)
+	}
+	for _, r := range tgt {
+		if _, ok := tgtByKey[r.Key]; ok {
+			rc.addDiff(tr, &tr.Extra, &tr.ExtraCount, r.Key)
+		}
+	}
+}
+
+func (rc *Reconciler) addDiff(tr *TableReport, list *[]string, count *int, key string) {
+	*count++
+	if len(*list) < rc.cfg.MaxDiffs {
+		*list = append(*list, key)
+	} else {
+		tr.Truncated = true
+	}
+}
+
+// rowHash menormalisasi nilai kolom supaya representasi MySQL & Postgres bisa dibandingkan
+// (mis. DECIMAL "100.00" vs numeric "100.0", tinyint 1 vs boolean true, offset timezone).
+func rowHash(vals []sql.NullString) string {
+	h := sha1.New()
+	for _, v := range vals {
+		if !v.Valid {
+			h.Write([]byte{0})
+		} else {
+			h.Write([]byte(normalizeValue(v.String)))
+		}
+		h.Write([]byte{0x1f})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func chunkChecksum(rows []keyedRow) string {
+	h := sha1.New()
+	for _, r := range rows {
+		h.Write([]byte(r.Key))
+		h.Write([]byte{0x1f})
+		h.Write([]byte(r.Hash))
+		h.Write([]byte{0x1e})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func normalizeValue(s string) string {
+	switch s {
+	case "true":
+		return "1"
+	case "false":
+		return "0"
+	}
+	// timestamp: samakan zona waktu (timestamptz bisa keluar dengan offset lokal)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	if strings.Contains(s, ".") {
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			s = strings.TrimRight(s, "0")
+			s = strings.TrimSuffix(s, ".")
+		}
+	}
+	return s
+}
+
+func worse(a, b string) string {
+	rank := map[string]int{"ok": 0, "mismatch": 1, "error": 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
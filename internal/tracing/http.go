@@ -0,0 +1,49 @@
+// internal/tracing/http.go
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware membuka satu span server per request lewat otelhttp, melanjutkan trace dari
+// header traceparent kalau ada. Nama span "<METHOD> <pola route chi>" diisi setelah routing;
+// 5xx menandai span error. Tracer nil = tracing dimatikan.
+func (t *Tracer) Middleware(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if reqID := middleware.GetReqID(r.Context()); reqID != "" {
+			span.SetAttributes(attribute.String("request_id", reqID))
+		}
+
+		next.ServeHTTP(w, r)
+
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rc.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rc.RoutePattern()))
+		}
+	})
+	return otelhttp.NewHandler(inner, "http.server",
+		otelhttp.WithTracerProvider(t.provider),
+		otelhttp.WithPropagators(propagator),
+		otelhttp.WithSpanNameFormatter(spanName),
+	)
+}
+
+// spanName dipanggil otelhttp saat span dibuka dan, kalau r.Pattern terisi (chi mengisinya
+// setelah routing), sekali lagi setelah handler selesai.
+func spanName(_ string, r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Method + " " + r.Pattern
+	}
+	return r.Method
+}
@@ -0,0 +1,36 @@
+// internal/tracing/sampler.go
+package tracing
+
+import (
+	"fmt"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewSampler membuat sampler dari nama OTEL_TRACES_SAMPLER. Varian parentbased_* mengikuti
+// flag sampled dari traceparent pemanggil dan hanya memakai sampler dasarnya untuk root
+// baru; arg = rasio 0..1 untuk *traceidratio. Nama kosong = parentbased_always_on.
+func NewSampler(name string, arg float64) (sdktrace.Sampler, error) {
+	switch name {
+	case "traceidratio", "parentbased_traceidratio":
+		if arg < 0 || arg > 1 {
+			return nil, fmt.Errorf("tracing: sampler ratio %v out of range (want 0..1)", arg)
+		}
+	}
+	switch name {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(arg), nil
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(arg)), nil
+	default:
+		return nil, fmt.Errorf("tracing: unknown sampler %q", name)
+	}
+}
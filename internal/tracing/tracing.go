@@ -0,0 +1,128 @@
+// internal/tracing/tracing.go
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Tracing memakai SDK OpenTelemetry: propagasi W3C traceparent/tracestate (+ baggage),
+// sampler standar OTEL_TRACES_SAMPLER dan exporter stdout atau OTLP/HTTP.
+
+const instrumentationName = "mini-poc-02/backend/internal/tracing"
+
+// propagator dipakai middleware (request masuk) dan Inject (request keluar).
+var propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{}, propagation.Baggage{},
+)
+
+// Config mengatur Tracer.
+type Config struct {
+	ServiceName string            // default "mks-backend"
+	Exporter    string            // stdout | otlp
+	Endpoint    string            // OTLP/HTTP, mis. http://otel-collector:4318 (/v1/traces ditambahkan otomatis)
+	Headers     map[string]string // header tambahan untuk OTLP
+	Sampler     string            // lihat NewSampler; default parentbased_always_on
+	SamplerArg  float64           // rasio untuk *traceidratio
+	Writer      io.Writer         // tujuan exporter stdout; default os.Stdout
+}
+
+// Tracer membungkus TracerProvider SDK. Semua method aman dipanggil pada *Tracer nil
+// (tracing dimatikan).
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New membuat exporter sesuai cfg.Exporter lalu TracerProvider dengan batch processor.
+func New(ctx context.Context, cfg Config) (*Tracer, error) {
+	var exp sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "stdout":
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, err
+		}
+		exp = e
+	case "otlp":
+		e, err := newOTLPExporter(ctx, cfg.Endpoint, cfg.Headers)
+		if err != nil {
+			return nil, err
+		}
+		exp = e
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q (want stdout or otlp)", cfg.Exporter)
+	}
+	sampler, err := NewSampler(cfg.Sampler, cfg.SamplerArg)
+	if err != nil {
+		return nil, err
+	}
+	return newTracer(cfg.ServiceName, sampler, sdktrace.WithBatcher(exp)), nil
+}
+
+func newTracer(service string, sampler sdktrace.Sampler, opts ...sdktrace.TracerProviderOption) *Tracer {
+	if service == "" {
+		service = "mks-backend"
+	}
+	opts = append(opts,
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service))),
+	)
+	tp := sdktrace.NewTracerProvider(opts...)
+	return &Tracer{provider: tp, tracer: tp.Tracer(instrumentationName)}
+}
+
+// newOTLPExporter: endpoint tanpa path akan ditambah /v1/traces.
+func newOTLPExporter(ctx context.Context, endpoint string, headers map[string]string) (sdktrace.SpanExporter, error) {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("tracing: OTLP endpoint %q must start with http:// or https://", endpoint)
+	}
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+	if len(headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(headers))
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// Start membuka span baru sebagai child span aktif di ctx (atau root baru). Tracer nil =
+// span no-op.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	return t.tracer.Start(ctx, name, opts...)
+}
+
+// Shutdown mengirim span yang masih antre lalu menutup exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// Inject menulis traceparent/tracestate span aktif di ctx ke header request keluar.
+func Inject(ctx context.Context, h http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(h))
+}
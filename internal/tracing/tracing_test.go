@@ -0,0 +1,313 @@
+// internal/tracing/tracing_test.go
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+// newTestTracer mengekspor span secara sinkron ke memori.
+func newTestTracer(t *testing.T, sampler sdktrace.Sampler) (*Tracer, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tr := newTracer("test", sampler, sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tr.Shutdown(context.Background()) })
+	return tr, exp
+}
+
+func TestMiddlewareTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantParent  bool
+		wantSampled bool
+	}{
+		{name: "sampled parent continues trace", traceparent: "00-" + parentTraceID + "-" + parentSpanID + "-01", wantParent: true, wantSampled: true},
+		{name: "unsampled parent is respected", traceparent: "00-" + parentTraceID + "-" + parentSpanID + "-00", wantParent: true, wantSampled: false},
+		{name: "invalid traceparent starts new root", traceparent: "00-" + parentTraceID + "-zz-01", wantSampled: true},
+		{name: "all-zero trace id starts new root", traceparent: "00-00000000000000000000000000000000-" + parentSpanID + "-01", wantSampled: true},
+		{name: "no traceparent", wantSampled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, exp := newTestTracer(t, sdktrace.ParentBased(sdktrace.AlwaysSample()))
+
+			var got trace.SpanContext
+			r := chi.NewRouter()
+			r.Use(tr.Middleware)
+			r.Get("/api/v1/customers/{customer_id}", func(w http.ResponseWriter, r *http.Request) {
+				got = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/customers/42", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !got.IsValid() {
+				t.Fatal("handler context has no valid span")
+			}
+			if sameTrace := got.TraceID().String() == parentTraceID; sameTrace != tt.wantParent {
+				t.Errorf("trace id = %s, continue parent = %v, want %v", got.TraceID(), sameTrace, tt.wantParent)
+			}
+			if got.IsSampled() != tt.wantSampled {
+				t.Errorf("sampled = %v, want %v", got.IsSampled(), tt.wantSampled)
+			}
+
+			spans := exp.GetSpans()
+			if !tt.wantSampled {
+				if len(spans) != 0 {
+					t.Errorf("exported %d spans for unsampled trace", len(spans))
+				}
+				return
+			}
+			if len(spans) != 1 {
+				t.Fatalf("exported %d spans, want 1", len(spans))
+			}
+			s := spans[0]
+			if s.Name != "GET /api/v1/customers/{customer_id}" {
+				t.Errorf("span name = %q", s.Name)
+			}
+			if s.SpanKind != trace.SpanKindServer {
+				t.Errorf("span kind = %v, want server", s.SpanKind)
+			}
+			if tt.wantParent && s.Parent.SpanID().String() != parentSpanID {
+				t.Errorf("parent span id = %s, want %s", s.Parent.SpanID(), parentSpanID)
+			}
+			if !tt.wantParent && s.Parent.IsValid() {
+				t.Errorf("root span has parent %s", s.Parent.SpanID())
+			}
+			if s.Status.Code.String() != "Error" {
+				t.Errorf("status = %v, want Error for 500", s.Status.Code)
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	tr, _ := newTestTracer(t, sdktrace.AlwaysSample())
+	ctx, span := tr.Start(context.Background(), "probe")
+	defer span.End()
+
+	h := http.Header{}
+	Inject(ctx, h)
+	sc := span.SpanContext()
+	want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"
+	if got := h.Get("traceparent"); got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+
+	h = http.Header{}
+	Inject(context.Background(), h)
+	if got := h.Get("traceparent"); got != "" {
+		t.Errorf("traceparent without span = %q, want empty", got)
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tr *Tracer
+	ctx, span := tr.Start(context.Background(), "noop")
+	span.End()
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Error("nil tracer produced a valid span context")
+	}
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	next := http.NotFoundHandler()
+	if h := tr.Middleware(next); h == nil {
+		t.Error("nil tracer middleware returned nil handler")
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	sampledParent := trace.ContextWithRemoteSpanContext(context.Background(), remoteParent(t, "01"))
+	unsampledParent := trace.ContextWithRemoteSpanContext(context.Background(), remoteParent(t, "00"))
+
+	tests := []struct {
+		name          string
+		sampler       string
+		arg           float64
+		wantErr       bool
+		root          bool // keputusan untuk root baru
+		withSampled   bool
+		withUnsampled bool
+	}{
+		{name: "default", sampler: "", root: true, withSampled: true, withUnsampled: false},
+		{name: "always_on", sampler: "always_on", root: true, withSampled: true, withUnsampled: true},
+		{name: "always_off", sampler: "always_off", root: false, withSampled: false, withUnsampled: false},
+		{name: "ratio 1", sampler: "traceidratio", arg: 1, root: true, withSampled: true, withUnsampled: true},
+		{name: "ratio 0", sampler: "traceidratio", arg: 0, root: false, withSampled: false, withUnsampled: false},
+		{name: "parentbased ratio 0", sampler: "parentbased_traceidratio", arg: 0, root: false, withSampled: true, withUnsampled: false},
+		{name: "parentbased always_off", sampler: "parentbased_always_off", root: false, withSampled: true, withUnsampled: false},
+		{name: "ratio out of range", sampler: "traceidratio", arg: 1.5, wantErr: true},
+		{name: "unknown", sampler: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSampler(tt.sampler, tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr, _ := newTestTracer(t, s)
+			for _, c := range []struct {
+				what string
+				ctx  context.Context
+				want bool
+			}{
+				{"root", context.Background(), tt.root},
+				{"sampled parent", sampledParent, tt.withSampled},
+				{"unsampled parent", unsampledParent, tt.withUnsampled},
+			} {
+				_, span := tr.Start(c.ctx, "op")
+				if got := span.SpanContext().IsSampled(); got != c.want {
+					t.Errorf("%s: sampled = %v, want %v", c.what, got, c.want)
+				}
+				span.End()
+			}
+		})
+	}
+}
+
+func remoteParent(t *testing.T, flags string) trace.SpanContext {
+	t.Helper()
+	tid, _ := trace.TraceIDFromHex(parentTraceID)
+	sid, _ := trace.SpanIDFromHex(parentSpanID)
+	cfg := trace.SpanContextConfig{TraceID: tid, SpanID: sid, Remote: true}
+	if flags == "01" {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(cfg)
+}
+
+func TestStdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tr, err := New(context.Background(), Config{ServiceName: "mks-test", Exporter: "stdout", Sampler: "always_on", Writer: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := tr.Start(context.Background(), "getCustomer")
+	span.End()
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		Name        string
+		SpanContext struct{ TraceID string }
+		Resource    []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+	}
+	if err := json.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("decode stdout span: %v (output %q)", err, buf.String())
+	}
+	if out.Name != "getCustomer" {
+		t.Errorf("name = %q", out.Name)
+	}
+	if out.SpanContext.TraceID != span.SpanContext().TraceID().String() {
+		t.Errorf("trace id = %q, want %s", out.SpanContext.TraceID, span.SpanContext().TraceID())
+	}
+	var service any
+	for _, kv := range out.Resource {
+		if kv.Key == "service.name" {
+			service = kv.Value.Value
+		}
+	}
+	if service != "mks-test" {
+		t.Errorf("service.name = %v, want mks-test", service)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		paths   []string
+		headers []http.Header
+		bodies  [][]byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		headers = append(headers, r.Header.Clone())
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer srv.Close()
+
+	tr, err := New(context.Background(), Config{
+		ServiceName: "mks-test",
+		Exporter:    "otlp",
+		Endpoint:    srv.URL + "/",
+		Headers:     map[string]string{"X-Scope-OrgID": "tenant-a"},
+		Sampler:     "always_on",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := tr.Start(context.Background(), "getCustomer")
+	span.End()
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 1 {
+		t.Fatalf("collector got %d requests, want 1", len(paths))
+	}
+	if paths[0] != "/v1/traces" {
+		t.Errorf("path = %q, want /v1/traces", paths[0])
+	}
+	if got := headers[0].Get("X-Scope-OrgID"); got != "tenant-a" {
+		t.Errorf("X-Scope-OrgID = %q, want tenant-a", got)
+	}
+	if got := headers[0].Get("Content-Type"); got != "application/x-protobuf" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if !bytes.Contains(bodies[0], []byte("getCustomer")) || !bytes.Contains(bodies[0], []byte("mks-test")) {
+		t.Error("OTLP payload does not contain span name and service name")
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Exporter: "jaeger"},
+		{Exporter: "otlp", Endpoint: "localhost:4318"},
+		{Exporter: "stdout", Writer: io.Discard, Sampler: "sometimes"},
+	} {
+		if _, err := New(context.Background(), cfg); err == nil {
+			t.Errorf("New(%+v): expected error", cfg)
+		} else if !strings.HasPrefix(err.Error(), "tracing: ") {
+			t.Errorf("New(%+v): error %q lacks package prefix", cfg, err)
+		}
+	}
+}